@@ -0,0 +1,73 @@
+/*
+Package cmd
+Copyright © 2025 NAME HERE anggarayusuf96@gmail.com
+*/
+package cmd
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/spf13/cobra"
+	"gopenapi/internal/generator"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// bundleCmd represents the bundle command
+var bundleCmd = &cobra.Command{
+	Use:   "bundle <spec>",
+	Short: "Bundle a spec split across files into a single document",
+	Long: `Load an OpenAPI spec and the files its $refs point to, and write a single
+self-contained document where the schemas, parameters and responses of the
+other files are components of the bundle. The result can be published on its
+own or given as input to generate. For example:
+
+  gopenapi bundle api/openapi.yaml -o openapi.bundle.json`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		doc := loadSpec(args[0])
+		writeSpec(cmd, doc, bundleOutput, bundleFormat)
+	},
+}
+
+var bundleOutput, bundleFormat string
+
+func init() {
+	rootCmd.AddCommand(bundleCmd)
+
+	bundleCmd.Flags().StringVarP(&bundleOutput, "output", "o", "", "File to write the bundle to instead of stdout")
+	bundleCmd.Flags().StringVar(&bundleFormat, "format", "yaml", "Output format: yaml or json, json by default when the output ends with .json")
+}
+
+// loadSpec loads the spec at path with its references internalized, and
+// logs the warnings of a swagger 2.0 conversion.
+func loadSpec(path string) *openapi3.T {
+	doc, warnings, err := generator.LoadSpec(path)
+	if err != nil {
+		log.Fatalf("failed to load %s: %v", path, err)
+	}
+	for _, warning := range warnings {
+		log.Printf("warning: %s", warning)
+	}
+	return doc
+}
+
+// writeSpec writes doc to output, or stdout when it is empty.
+func writeSpec(cmd *cobra.Command, doc *openapi3.T, output, format string) {
+	if !cmd.Flags().Changed("format") && strings.EqualFold(filepath.Ext(output), ".json") {
+		format = "json"
+	}
+	data, err := generator.MarshalSpec(doc, format)
+	if err != nil {
+		log.Fatalf("failed to encode spec: %v", err)
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(output, data, 0644)
+	}
+	if err != nil {
+		log.Fatalf("failed to write spec: %v", err)
+	}
+}
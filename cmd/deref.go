@@ -0,0 +1,40 @@
+/*
+Package cmd
+Copyright © 2025 NAME HERE anggarayusuf96@gmail.com
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"gopenapi/internal/generator"
+	"log"
+)
+
+// derefCmd represents the deref command
+var derefCmd = &cobra.Command{
+	Use:   "deref <spec>",
+	Short: "Inline every reference of a spec",
+	Long: `Load an OpenAPI spec and the files its $refs point to, and write a single
+document where every reference is replaced by what it points to, for tools
+that do not resolve references. A schema that refers to itself keeps its
+reference and stays a component, with a warning. For example:
+
+  gopenapi deref api/openapi.yaml -o openapi.deref.yaml`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		doc := loadSpec(args[0])
+		for _, warning := range generator.Dereference(doc) {
+			log.Printf("warning: %s", warning)
+		}
+		writeSpec(cmd, doc, derefOutput, derefFormat)
+	},
+}
+
+var derefOutput, derefFormat string
+
+func init() {
+	rootCmd.AddCommand(derefCmd)
+
+	derefCmd.Flags().StringVarP(&derefOutput, "output", "o", "", "File to write the dereferenced spec to instead of stdout")
+	derefCmd.Flags().StringVar(&derefFormat, "format", "yaml", "Output format: yaml or json, json by default when the output ends with .json")
+}
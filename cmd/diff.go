@@ -0,0 +1,96 @@
+/*
+Package cmd
+Copyright © 2025 NAME HERE anggarayusuf96@gmail.com
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"gopenapi/internal/config"
+	"gopenapi/internal/generator"
+	"gopenapi/internal/godiff"
+	"gopenapi/internal/specdiff"
+	"log"
+	"os"
+	"strings"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <base> <revision>",
+	Short: "Report the changes between two versions of an OpenAPI spec",
+	Long: `Compare the operations, parameters, request and response schemas and
+enums of two versions of an OpenAPI spec, and classify each change as
+breaking or not for existing clients. With --go, compare instead the exported
+Go API generated from both versions with the settings of gopenapi.yaml. For
+example:
+
+  gopenapi diff main/petstore.yaml petstore.yaml --format markdown`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		diff(args[0], args[1])
+	},
+}
+
+var (
+	diffFormat     string
+	failOnBreaking bool
+	goAPI          bool
+)
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "Report format: "+strings.Join(specdiff.Formats, ", "))
+	diffCmd.Flags().BoolVar(&failOnBreaking, "fail-on-breaking", false, "Exit with status 1 when there are breaking changes")
+	diffCmd.Flags().BoolVar(&goAPI, "go", false, "Compare the exported types, fields, methods and handlers generated from both specs")
+}
+
+func diff(basePath, revisionPath string) {
+	var changes []specdiff.Change
+	if goAPI {
+		changes = diffGo(basePath, revisionPath)
+	} else {
+		base, _, err := generator.LoadSpec(basePath)
+		if err != nil {
+			log.Fatalf("failed to load %s: %v", basePath, err)
+		}
+		revision, _, err := generator.LoadSpec(revisionPath)
+		if err != nil {
+			log.Fatalf("failed to load %s: %v", revisionPath, err)
+		}
+		changes = specdiff.Compare(base, revision)
+	}
+	if err := specdiff.Write(os.Stdout, changes, diffFormat); err != nil {
+		log.Fatalf("failed to write report: %v", err)
+	}
+	if failOnBreaking && specdiff.Breaking(changes) > 0 {
+		os.Exit(1)
+	}
+}
+
+// diffGo generates the code of both specs in memory and compares their
+// exported Go API.
+func diffGo(basePath, revisionPath string) []specdiff.Change {
+	cfg, err := config.ParseConfig("gopenapi.yaml")
+	if err != nil {
+		log.Fatalf("failed to parse config: %v", err)
+	}
+	if len(cfg.Targets) > 0 {
+		log.Fatal("diff --go does not support configs with targets")
+	}
+	render := func(input string) map[string][]byte {
+		specCfg := *cfg
+		specCfg.Input = input
+		files, err := generator.NewGenerator(&specCfg).Render()
+		if err != nil {
+			log.Fatalf("failed to generate %s: %v", input, err)
+		}
+		return files
+	}
+	changes, err := godiff.Compare(render(basePath), render(revisionPath))
+	if err != nil {
+		log.Fatalf("failed to compare generated code: %v", err)
+	}
+	return changes
+}
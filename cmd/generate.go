@@ -5,10 +5,13 @@ Copyright © 2025 NAME HERE anggarayusuf96@gmail.com
 package cmd
 
 import (
+	"context"
 	"github.com/spf13/cobra"
 	"gopenapi/internal/config"
 	"gopenapi/internal/generator"
 	"log"
+	"os"
+	"os/signal"
 )
 
 // generateCmd represents the generate command
@@ -26,6 +29,8 @@ to quickly create a Cobra application.`,
 	},
 }
 
+var force, check, dryRun, stdout, watch bool
+
 func init() {
 	rootCmd.AddCommand(generateCmd)
 
@@ -36,13 +41,30 @@ func init() {
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
 	// generateCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	generateCmd.Flags().BoolVar(&force, "force", false, "Remove stale generated files even when they were edited")
+	generateCmd.Flags().BoolVar(&check, "check", false, "Fail with a diff when the generated files are out of date, without writing them")
+	generateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the files that would be generated and their sizes, without writing them")
+	generateCmd.Flags().BoolVar(&stdout, "stdout", false, "Write the generated files to stdout, separated by their paths, instead of the output directory")
+	generateCmd.Flags().BoolVar(&watch, "watch", false, "Regenerate whenever the config, the spec, a file it references or a template changes")
+	generateCmd.MarkFlagsMutuallyExclusive("check", "dry-run", "stdout", "watch")
 }
 
 func generate() {
-	cfg, err := config.ParseConfig("gopenapi.yaml")
+	const configPath = "gopenapi.yaml"
+	if watch {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		generator.Watch(ctx, configPath, force)
+		return
+	}
+	cfg, err := config.ParseConfig(configPath)
 	if err != nil {
 		log.Fatalf("failed to parse config: %v", err)
 	}
 	gen := generator.NewGenerator(cfg)
+	gen.Force = force
+	gen.Check = check
+	gen.DryRun = dryRun
+	gen.Stdout = stdout
 	gen.Generate()
 }
@@ -0,0 +1,93 @@
+/*
+Package cmd
+Copyright © 2025 NAME HERE anggarayusuf96@gmail.com
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/oasdiff/yaml"
+	"github.com/spf13/cobra"
+	"gopenapi/internal/overlay"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// overlayCmd represents the overlay command
+var overlayCmd = &cobra.Command{
+	Use:   "overlay",
+	Short: "Work with OpenAPI Overlay documents",
+	Long: `OpenAPI Overlay 1.0 documents patch a spec that cannot be edited, such as a
+vendor one, with actions updating or removing the nodes selected by JSONPath
+queries. List them under overlays in gopenapi.yaml to apply them before
+generation.`,
+}
+
+// overlayApplyCmd represents the overlay apply command
+var overlayApplyCmd = &cobra.Command{
+	Use:   "apply <spec> <overlay>...",
+	Short: "Apply overlays to a spec and write the patched spec",
+	Long: `Apply the overlays in order to a spec and write the patched spec, to review
+what generate will see. Relative references of the spec are left as they are.
+For example:
+
+  gopenapi overlay apply vendor/openapi.yaml overlays/operation-ids.yaml`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		applyOverlays(cmd, args[0], args[1:])
+	},
+}
+
+var overlayOutput, overlayFormat string
+
+func init() {
+	rootCmd.AddCommand(overlayCmd)
+	overlayCmd.AddCommand(overlayApplyCmd)
+
+	overlayApplyCmd.Flags().StringVarP(&overlayOutput, "output", "o", "", "File to write the patched spec to instead of stdout")
+	overlayApplyCmd.Flags().StringVar(&overlayFormat, "format", "yaml", "Output format: yaml or json, json by default when the output ends with .json")
+}
+
+func applyOverlays(cmd *cobra.Command, specPath string, overlays []string) {
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		log.Fatalf("failed to read spec: %v", err)
+	}
+	data, warnings, err := overlay.ApplyFiles(data, overlays)
+	for _, warning := range warnings {
+		log.Printf("warning: %s", warning)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	format := overlayFormat
+	if !cmd.Flags().Changed("format") && strings.EqualFold(filepath.Ext(overlayOutput), ".json") {
+		format = "json"
+	}
+	switch format {
+	case "yaml":
+	case "json":
+		if data, err = yaml.YAMLToJSON(data); err != nil {
+			log.Fatalf("failed to encode spec: %v", err)
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, data, "", "  "); err != nil {
+			log.Fatalf("failed to encode spec: %v", err)
+		}
+		data = append(out.Bytes(), '\n')
+	default:
+		log.Fatalf("unknown spec format %q, expected yaml or json", format)
+	}
+	if overlayOutput == "" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(overlayOutput, data, 0644)
+	}
+	if err != nil {
+		log.Fatalf("failed to write spec: %v", err)
+	}
+}
@@ -2,17 +2,35 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"gopkg.in/yaml.v3"
 	"os"
 )
 
 type Config struct {
-	Module     string     `yaml:"module"`
-	Input      string     `yaml:"input"`
+	// Module overrides the module path of the nearest go.mod above the
+	// output directory, used to import the generated models.
+	Module string `yaml:"module"`
+	Input  string `yaml:"input"`
+	// Overlays are OpenAPI Overlay 1.0 files applied in order to the input
+	// before it is mapped.
+	Overlays   []string   `yaml:"overlays"`
 	Output     string     `yaml:"output"`
 	Packages   Package    `yaml:"packages"`
 	Options    Option     `yaml:"options"`
 	FileNaming FileNaming `yaml:"fileNaming"`
+	StructTags StructTags `yaml:"structTags"`
+	Filter     Filter     `yaml:"filter"`
+	// Name identifies a target in logs and errors, by default its input and
+	// output, e.g. "pets.yaml -> gen/pets", or its input without output.
+	Name string `yaml:"name"`
+	// Targets generates several specs in one run. Each target is a config
+	// whose unset fields take the value they have at the top level.
+	Targets []*Config `yaml:"-"`
+	// SharedModels lets targets generating into the same models directory
+	// share it: the model files they have in common are written once, and
+	// those that differ are reported as conflicts.
+	SharedModels bool `yaml:"sharedModels"`
 }
 
 type Package struct {
@@ -25,6 +43,55 @@ type Option struct {
 	SplitAPIs           bool `yaml:"splitAPIs"`
 	InlineNestedSchemas bool `yaml:"inlineNestedSchemas"`
 	GenerateRegister    bool `yaml:"generateRegister"`
+	GenerateValidation  bool `yaml:"generateValidation"`
+	// GenerateClient adds a Client calling the operations next to the
+	// handlers of every API package.
+	GenerateClient bool `yaml:"generateClient"`
+	// UnmarshalDefaults fills fields absent from decoded JSON with their
+	// schema defaults.
+	UnmarshalDefaults bool `yaml:"unmarshalDefaults"`
+	// ReadWriteOnly selects how readOnly and writeOnly properties are
+	// handled: marshal (default), variants or ignore.
+	ReadWriteOnly string `yaml:"readWriteOnly"`
+	// APILayout places every API file in the API package (single, default)
+	// or each tag in its own package below it (tag).
+	APILayout string `yaml:"apiLayout"`
+}
+
+// StructTags selects the tag families emitted on model fields in addition
+// to json.
+type StructTags struct {
+	Binding  bool `yaml:"binding"`
+	Validate bool `yaml:"validate"`
+	XML      bool `yaml:"xml"`
+	YAML     bool `yaml:"yaml"`
+	Form     bool `yaml:"form"`
+	// DB enables db tags named with the given strategy: snake, camel,
+	// pascal or original.
+	DB string `yaml:"db"`
+}
+
+// Filter selects the operations and schemas to generate. Operations are
+// generated when they match any include criterion, or when there is none,
+// and no exclude criterion. Once a filter is set, only the schemas reachable
+// from the generated operations or included by name are generated.
+type Filter struct {
+	Include FilterSet `yaml:"include"`
+	Exclude FilterSet `yaml:"exclude"`
+}
+
+type FilterSet struct {
+	Tags         []string `yaml:"tags"`
+	OperationIDs []string `yaml:"operationIds"`
+	// Paths are globs where * matches within a path segment and ** across
+	// segments, e.g. /pets/** or /users/*/orders.
+	Paths   []string `yaml:"paths"`
+	Schemas []string `yaml:"schemas"`
+}
+
+// IsEmpty reports whether the filter set has no criterion.
+func (f FilterSet) IsEmpty() bool {
+	return len(f.Tags) == 0 && len(f.OperationIDs) == 0 && len(f.Paths) == 0 && len(f.Schemas) == 0
 }
 
 type FileNaming struct {
@@ -37,13 +104,59 @@ func ParseConfig(path string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	var file struct {
+		Config  `yaml:",inline"`
+		Targets []yaml.Node `yaml:"targets"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
 		return nil, err
 	}
+	if len(file.Targets) == 0 {
+		return file.Config.normalize()
+	}
+
+	cfg := file.Config
+	names := map[string]bool{}
+	for i, node := range file.Targets {
+		// Fields of the target replace those inherited from the top level.
+		target := file.Config
+		if err := node.Decode(&target); err != nil {
+			return nil, fmt.Errorf("target %d: %w", i+1, err)
+		}
+		normalized, err := target.normalize()
+		if err != nil {
+			return nil, fmt.Errorf("target %d: %w", i+1, err)
+		}
+		if names[normalized.Name] {
+			return nil, fmt.Errorf("target %d: duplicate target name %q", i+1, normalized.Name)
+		}
+		names[normalized.Name] = true
+		cfg.Targets = append(cfg.Targets, normalized)
+	}
+	return &cfg, nil
+}
+
+// Generations returns the configs to generate: the targets, or the config
+// itself when it has none.
+func (c *Config) Generations() []*Config {
+	if len(c.Targets) > 0 {
+		return c.Targets
+	}
+	return []*Config{c}
+}
+
+// normalize checks a config declaring a single generation and fills in its
+// defaults.
+func (cfg Config) normalize() (*Config, error) {
 	if cfg.Input == "" {
 		return nil, errors.New("input is required")
 	}
+	if cfg.Name == "" {
+		cfg.Name = cfg.Input
+		if cfg.Output != "" {
+			cfg.Name += " -> " + cfg.Output
+		}
+	}
 	if cfg.Packages.Models == "" {
 		cfg.Packages.Models = "models"
 	}
@@ -56,5 +169,20 @@ func ParseConfig(path string) (*Config, error) {
 	if cfg.FileNaming.APISuffix == "" {
 		cfg.FileNaming.APISuffix = "_api.go"
 	}
+	switch cfg.StructTags.DB {
+	case "", "snake", "camel", "pascal", "original":
+	default:
+		return nil, fmt.Errorf("unknown structTags.db naming strategy %q", cfg.StructTags.DB)
+	}
+	switch cfg.Options.ReadWriteOnly {
+	case "", "marshal", "variants", "ignore":
+	default:
+		return nil, fmt.Errorf("unknown options.readWriteOnly mode %q", cfg.Options.ReadWriteOnly)
+	}
+	switch cfg.Options.APILayout {
+	case "", "single", "tag":
+	default:
+		return nil, fmt.Errorf("unknown options.apiLayout %q", cfg.Options.APILayout)
+	}
 	return &cfg, nil
 }
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig_Targets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gopenapi.yaml")
+	write := func(data string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`
+output: gen
+sharedModels: true
+options: {splitModels: true, apiLayout: tag}
+structTags: {db: snake}
+targets:
+  - name: pets
+    input: pets.yaml
+    packages: {api: petapi}
+  - input: users.yaml
+    output: gen/users
+    options: {apiLayout: single}
+`)
+	cfg, err := ParseConfig(path)
+	if err != nil {
+		t.Fatalf("ParseConfig: %v", err)
+	}
+	if !cfg.SharedModels || len(cfg.Generations()) != 2 {
+		t.Fatalf("expected two shared targets, got %+v", cfg)
+	}
+	pets, users := cfg.Targets[0], cfg.Targets[1]
+	if pets.Name != "pets" || pets.Output != "gen" || pets.Packages.API != "petapi" || pets.Packages.Models != "models" {
+		t.Errorf("expected pets to inherit the output and default the models package, got %+v", pets)
+	}
+	if !pets.Options.SplitModels || pets.Options.APILayout != "tag" || pets.StructTags.DB != "snake" {
+		t.Errorf("expected pets to inherit the options, got %+v", pets.Options)
+	}
+	if users.Name != "users.yaml -> gen/users" || users.Output != "gen/users" || users.Packages.API != "api" {
+		t.Errorf("expected users to be named after its input and output, got %+v", users)
+	}
+	if !users.Options.SplitModels || users.Options.APILayout != "single" {
+		t.Errorf("expected users to override only apiLayout, got %+v", users.Options)
+	}
+
+	write(`
+targets:
+  - {input: a.yaml, output: gen/v1}
+  - {input: a.yaml, output: gen/v2}
+`)
+	if cfg, err = ParseConfig(path); err != nil {
+		t.Fatalf("expected one spec to generate into several outputs, got %v", err)
+	}
+	if cfg.Targets[0].Name != "a.yaml -> gen/v1" || cfg.Targets[1].Name != "a.yaml -> gen/v2" {
+		t.Errorf("expected the targets to be named after their output, got %q and %q", cfg.Targets[0].Name, cfg.Targets[1].Name)
+	}
+
+	single := &Config{Input: "spec.yaml"}
+	if got := single.Generations(); len(got) != 1 || got[0] != single {
+		t.Errorf("expected a config without targets to generate itself, got %v", got)
+	}
+
+	for config, want := range map[string]string{
+		"targets: [{output: gen}]":                                 "target 1: input is required",
+		"targets: [{input: a.yaml}, {input: a.yaml}]":              "duplicate target name",
+		"output: gen\ntargets: [{input: a.yaml}, {input: a.yaml}]": `target 2: duplicate target name "a.yaml -> gen"`,
+		"targets: [{input: a.yaml, structTags: {db: x}}]":          "target 1: unknown structTags.db",
+	} {
+		write(config)
+		if _, err := ParseConfig(path); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected an error containing %q, got %v", config, want, err)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+)
+
+// MarshalSpec encodes doc as yaml or json. YAML documents start with the
+// usual top-level fields instead of sorting them.
+func MarshalSpec(doc *openapi3.T, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case "yaml":
+		var node yaml.Node
+		if err := node.Encode(doc); err != nil {
+			return nil, err
+		}
+		sortTopLevel(&node)
+		var out strings.Builder
+		encoder := yaml.NewEncoder(&out)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&node); err != nil {
+			return nil, err
+		}
+		return []byte(out.String()), nil
+	}
+	return nil, fmt.Errorf("unknown spec format %q, expected yaml or json", format)
+}
+
+var topLevelOrder = []string{
+	"openapi", "info", "jsonSchemaDialect", "servers", "security", "tags",
+	"externalDocs", "paths", "webhooks", "components",
+}
+
+func sortTopLevel(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	rank := func(key string) int {
+		if i := slices.Index(topLevelOrder, key); i >= 0 {
+			return i
+		}
+		return len(topLevelOrder)
+	}
+	pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return rank(pairs[i][0].Value) < rank(pairs[j][0].Value) })
+	node.Content = node.Content[:0]
+	for _, pair := range pairs {
+		node.Content = append(node.Content, pair[0], pair[1])
+	}
+}
+
+// Dereference replaces the references of doc by copies of what they point
+// to. References that would recurse forever keep pointing to their schema
+// component, the other components but the security schemes are removed. It
+// returns a warning per schema kept.
+func Dereference(doc *openapi3.T) []string {
+	d := dereferencer{kept: map[string]bool{}}
+	if doc.Paths != nil {
+		paths := openapi3.NewPathsWithCapacity(doc.Paths.Len())
+		for path, item := range doc.Paths.Map() {
+			paths.Set(path, d.pathItem(item))
+		}
+		doc.Paths = paths
+	}
+	if doc.Components == nil {
+		return nil
+	}
+
+	// Kept schemas may refer to other schemas that recurse.
+	schemas := openapi3.Schemas{}
+	for done := false; !done; {
+		done = true
+		for ref := range d.kept {
+			name := strings.TrimPrefix(ref, "#/components/schemas/")
+			if _, ok := schemas[name]; ok {
+				continue
+			}
+			if original := doc.Components.Schemas[name]; original != nil {
+				schemas[name] = d.schema(&openapi3.SchemaRef{Value: original.Value})
+				done = false
+			}
+		}
+	}
+	var warnings []string
+	for name := range schemas {
+		warnings = append(warnings, fmt.Sprintf("schema %s is recursive and kept as a component", name))
+	}
+	sort.Strings(warnings)
+	components := doc.Components
+	doc.Components = nil
+	if len(schemas) > 0 || len(components.SecuritySchemes) > 0 || len(components.Extensions) > 0 {
+		doc.Components = &openapi3.Components{
+			Extensions:      components.Extensions,
+			SecuritySchemes: components.SecuritySchemes,
+		}
+		if len(schemas) > 0 {
+			doc.Components.Schemas = schemas
+		}
+	}
+	return warnings
+}
+
+// dereferencer copies the parts of a document it inlines, so values shared
+// by several references are never modified.
+type dereferencer struct {
+	// stack holds the schemas being inlined, referring to one of them again
+	// would recurse.
+	stack []*openapi3.Schema
+	// kept holds the references left in place.
+	kept map[string]bool
+}
+
+func (d *dereferencer) schema(ref *openapi3.SchemaRef) *openapi3.SchemaRef {
+	if ref == nil || ref.Value == nil {
+		return ref
+	}
+	if slices.Contains(d.stack, ref.Value) && ref.Ref != "" {
+		d.kept[ref.Ref] = true
+		return &openapi3.SchemaRef{Ref: ref.Ref, Value: ref.Value}
+	}
+	d.stack = append(d.stack, ref.Value)
+	defer func() { d.stack = d.stack[:len(d.stack)-1] }()
+
+	schema := *ref.Value
+	if schema.Properties != nil {
+		schema.Properties = make(openapi3.Schemas, len(ref.Value.Properties))
+		for name, prop := range ref.Value.Properties {
+			schema.Properties[name] = d.schema(prop)
+		}
+	}
+	schema.Items = d.schema(schema.Items)
+	schema.Not = d.schema(schema.Not)
+	schema.AdditionalProperties.Schema = d.schema(schema.AdditionalProperties.Schema)
+	schema.AllOf = d.schemas(schema.AllOf)
+	schema.AnyOf = d.schemas(schema.AnyOf)
+	schema.OneOf = d.schemas(schema.OneOf)
+	return &openapi3.SchemaRef{Extensions: ref.Extensions, Value: &schema}
+}
+
+func (d *dereferencer) schemas(refs openapi3.SchemaRefs) openapi3.SchemaRefs {
+	if refs == nil {
+		return nil
+	}
+	copied := make(openapi3.SchemaRefs, len(refs))
+	for i, ref := range refs {
+		copied[i] = d.schema(ref)
+	}
+	return copied
+}
+
+func (d *dereferencer) pathItem(item *openapi3.PathItem) *openapi3.PathItem {
+	copied := *item
+	copied.Ref = ""
+	copied.Parameters = d.parameters(item.Parameters)
+	for method, operation := range item.Operations() {
+		copied.SetOperation(method, d.operation(operation))
+	}
+	return &copied
+}
+
+func (d *dereferencer) operation(operation *openapi3.Operation) *openapi3.Operation {
+	copied := *operation
+	copied.Parameters = d.parameters(operation.Parameters)
+	if ref := operation.RequestBody; ref != nil && ref.Value != nil {
+		body := *ref.Value
+		body.Content = d.content(body.Content)
+		copied.RequestBody = &openapi3.RequestBodyRef{Value: &body}
+	}
+	if operation.Responses != nil {
+		copied.Responses = openapi3.NewResponsesWithCapacity(operation.Responses.Len())
+		for status, ref := range operation.Responses.Map() {
+			copied.Responses.Set(status, d.response(ref))
+		}
+	}
+	if operation.Callbacks != nil {
+		copied.Callbacks = make(openapi3.Callbacks, len(operation.Callbacks))
+		for name, ref := range operation.Callbacks {
+			if ref.Value == nil {
+				continue
+			}
+			callback := openapi3.NewCallbackWithCapacity(ref.Value.Len())
+			for expression, item := range ref.Value.Map() {
+				callback.Set(expression, d.pathItem(item))
+			}
+			copied.Callbacks[name] = &openapi3.CallbackRef{Value: callback}
+		}
+	}
+	return &copied
+}
+
+func (d *dereferencer) parameters(params openapi3.Parameters) openapi3.Parameters {
+	if params == nil {
+		return nil
+	}
+	copied := make(openapi3.Parameters, 0, len(params))
+	for _, ref := range params {
+		if ref.Value == nil {
+			continue
+		}
+		param := *ref.Value
+		param.Schema = d.schema(param.Schema)
+		param.Content = d.content(param.Content)
+		param.Examples = examples(param.Examples)
+		copied = append(copied, &openapi3.ParameterRef{Value: &param})
+	}
+	return copied
+}
+
+func (d *dereferencer) response(ref *openapi3.ResponseRef) *openapi3.ResponseRef {
+	if ref == nil || ref.Value == nil {
+		return ref
+	}
+	resp := *ref.Value
+	resp.Content = d.content(resp.Content)
+	if resp.Headers != nil {
+		resp.Headers = make(openapi3.Headers, len(ref.Value.Headers))
+		for name, header := range ref.Value.Headers {
+			if header.Value == nil {
+				continue
+			}
+			copied := *header.Value
+			copied.Schema = d.schema(copied.Schema)
+			copied.Content = d.content(copied.Content)
+			copied.Examples = examples(copied.Examples)
+			resp.Headers[name] = &openapi3.HeaderRef{Value: &copied}
+		}
+	}
+	if resp.Links != nil {
+		resp.Links = make(openapi3.Links, len(ref.Value.Links))
+		for name, link := range ref.Value.Links {
+			resp.Links[name] = &openapi3.LinkRef{Value: link.Value}
+		}
+	}
+	return &openapi3.ResponseRef{Value: &resp}
+}
+
+func (d *dereferencer) content(content openapi3.Content) openapi3.Content {
+	if content == nil {
+		return nil
+	}
+	copied := make(openapi3.Content, len(content))
+	for mediaType, media := range content {
+		if media == nil {
+			continue
+		}
+		m := *media
+		m.Schema = d.schema(m.Schema)
+		m.Examples = examples(m.Examples)
+		copied[mediaType] = &m
+	}
+	return copied
+}
+
+func examples(examples openapi3.Examples) openapi3.Examples {
+	if examples == nil {
+		return nil
+	}
+	copied := make(openapi3.Examples, len(examples))
+	for name, ref := range examples {
+		copied[name] = &openapi3.ExampleRef{Value: ref.Value}
+	}
+	return copied
+}
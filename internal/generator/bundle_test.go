@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const recursiveSpec = `
+openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /nodes:
+    get:
+      parameters:
+        - $ref: '#/components/parameters/Limit'
+      responses:
+        "200":
+          $ref: '#/components/responses/Nodes'
+components:
+  parameters:
+    Limit: {name: limit, in: query, schema: {type: integer}}
+  responses:
+    Nodes:
+      description: ok
+      content:
+        application/json:
+          schema: {type: array, items: {$ref: '#/components/schemas/Node'}}
+  schemas:
+    Node:
+      type: object
+      properties:
+        children: {type: array, items: {$ref: '#/components/schemas/Node'}}
+        owner: {$ref: '#/components/schemas/Owner'}
+    Owner:
+      type: object
+      properties:
+        name: {type: string}
+`
+
+func TestDereference(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(recursiveSpec))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	owner := doc.Components.Schemas["Node"].Value.Properties["owner"]
+
+	warnings := Dereference(doc)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "Node") {
+		t.Errorf("expected a warning for Node, got %v", warnings)
+	}
+	if owner.Ref == "" {
+		t.Errorf("expected the loaded schemas to be left unchanged")
+	}
+
+	operation := doc.Paths.Find("/nodes").Get
+	if param := operation.Parameters[0]; param.Ref != "" || param.Value.Name != "limit" {
+		t.Errorf("expected the parameter to be inlined, got %+v", param)
+	}
+	resp := operation.Responses.Value("200")
+	if resp.Ref != "" {
+		t.Errorf("expected the response to be inlined, got %s", resp.Ref)
+	}
+	node := resp.Value.Content.Get("application/json").Schema.Value.Items
+	if node.Ref != "" {
+		t.Errorf("expected the array items to be inlined, got %s", node.Ref)
+	}
+	if ref := node.Value.Properties["owner"].Ref; ref != "" {
+		t.Errorf("expected owner to be inlined, got %s", ref)
+	}
+	if ref := node.Value.Properties["children"].Value.Items.Ref; ref != "#/components/schemas/Node" {
+		t.Errorf("expected children to keep referring to Node, got %q", ref)
+	}
+
+	if doc.Components.Parameters != nil || doc.Components.Responses != nil {
+		t.Errorf("expected the inlined components to be removed")
+	}
+	if _, ok := doc.Components.Schemas["Owner"]; ok || len(doc.Components.Schemas) != 1 {
+		t.Errorf("expected only Node to be kept, got %v", doc.Components.Schemas)
+	}
+	if err := doc.Validate(openapi3.NewLoader().Context); err != nil {
+		t.Errorf("expected a valid document, got %v", err)
+	}
+}
+
+func TestMarshalSpec_Bundle(t *testing.T) {
+	tmp := t.TempDir()
+	specPath := filepath.Join(tmp, "api.yaml")
+	mustWriteFile(t, specPath, []byte(externalSpec))
+	mustWriteFile(t, filepath.Join(tmp, "schemas.yaml"), []byte("Thing: {type: object, properties: {name: {type: string}}}\n"))
+
+	doc, _, err := LoadSpec(specPath)
+	if err != nil {
+		t.Fatalf("LoadSpec: %v", err)
+	}
+	data, err := MarshalSpec(doc, "yaml")
+	if err != nil {
+		t.Fatalf("MarshalSpec: %v", err)
+	}
+	bundle := string(data)
+	if !strings.HasPrefix(bundle, "openapi: 3.0.3\ninfo:") {
+		t.Errorf("expected the bundle to start with the openapi version and info, got:\n%s", bundle)
+	}
+	if strings.Contains(bundle, "schemas.yaml") {
+		t.Errorf("expected the external reference to be internalized, got:\n%s", bundle)
+	}
+
+	bundlePath := filepath.Join(tmp, "bundle.yaml")
+	mustWriteFile(t, bundlePath, data)
+	if err := os.Remove(filepath.Join(tmp, "schemas.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, _, err := LoadSpec(bundlePath)
+	if err != nil {
+		t.Fatalf("expected the bundle to load on its own, got %v", err)
+	}
+	if len(reloaded.Components.Schemas) != 1 {
+		t.Errorf("expected the bundled schema, got %v", reloaded.Components.Schemas)
+	}
+
+	if _, err := MarshalSpec(doc, "toml"); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
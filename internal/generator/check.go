@@ -0,0 +1,68 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// checkFiles compares the files rendered into mem with those on disk and
+// writes a unified diff of each one out of date to w. Files of the previous
+// generation that a run would remove count as out of date too, those edited
+// since generation with a hint to remove them with --force. It returns the
+// number of files out of date.
+func checkFiles(w io.Writer, baseOut string, mem *memorySink) (int, error) {
+	outdated := 0
+	generated := map[string]bool{}
+	for _, path := range mem.paths {
+		if rel, err := filepath.Rel(baseOut, path); err == nil {
+			generated[filepath.ToSlash(rel)] = true
+		}
+		current, err := os.ReadFile(path)
+		fromName := "a/" + filepath.ToSlash(path)
+		if errors.Is(err, os.ErrNotExist) {
+			fromName = "/dev/null"
+		} else if err != nil {
+			return outdated, err
+		}
+		if bytes.Equal(current, mem.files[path]) {
+			continue
+		}
+		outdated++
+		fmt.Fprint(w, unifiedDiff(fromName, "b/"+filepath.ToSlash(path), current, mem.files[path]))
+	}
+
+	previous, err := readManifest(filepath.Join(baseOut, manifestFile))
+	if err != nil {
+		return outdated, err
+	}
+	var stale []string
+	for rel := range previous.Files {
+		if !generated[rel] && isLocal(rel) {
+			stale = append(stale, rel)
+		}
+	}
+	sort.Strings(stale)
+	for _, rel := range stale {
+		actual, err := hashFile(filepath.Join(baseOut, filepath.FromSlash(rel)))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return outdated, err
+		}
+		outdated++
+		path := filepath.ToSlash(filepath.Join(baseOut, rel))
+		if actual != previous.Files[rel] {
+			// A run keeps edited stale files, only --force removes them.
+			fmt.Fprintf(w, "stale, modified %s is no longer generated and was edited since generation; run with --force to remove it\n", path)
+			continue
+		}
+		fmt.Fprintf(w, "stale %s is no longer generated\n", path)
+	}
+	return outdated, nil
+}
@@ -0,0 +1,150 @@
+package generator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "package models\n\ntype Pet struct {\n\tID int\n\tName string\n}\n"
+	b := "package models\n\ntype Pet struct {\n\tID int\n\tName string\n\tTag string\n}\n"
+	want := `--- a/pet.go
++++ b/pet.go
+@@ -3,4 +3,5 @@
+ type Pet struct {
+ 	ID int
+ 	Name string
++	Tag string
+ }
+`
+	if got := unifiedDiff("a/pet.go", "b/pet.go", []byte(a), []byte(b)); got != want {
+		t.Errorf("expected diff\n%s\ngot\n%s", want, got)
+	}
+	if got := unifiedDiff("a/pet.go", "b/pet.go", []byte(a), []byte(a)); got != "" {
+		t.Errorf("expected no diff for equal files, got\n%s", got)
+	}
+}
+
+func TestUnifiedDiff_SeparateHunks(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 20; i++ {
+		line := string(rune('a' + i))
+		a = append(a, line)
+		switch i {
+		case 2:
+			b = append(b, "C")
+		case 15:
+		default:
+			b = append(b, line)
+		}
+	}
+	got := unifiedDiff("a/f", "b/f", []byte(strings.Join(a, "\n")+"\n"), []byte(strings.Join(b, "\n")+"\n"))
+	for _, header := range []string{"@@ -1,6 +1,6 @@", "@@ -13,7 +13,6 @@"} {
+		if !strings.Contains(got, header) {
+			t.Errorf("expected hunk %s, got\n%s", header, got)
+		}
+	}
+	if !strings.Contains(got, "-c\n+C\n") || !strings.Contains(got, "-p\n") {
+		t.Errorf("unexpected changes in\n%s", got)
+	}
+}
+
+func TestDiffLines_Interleaved(t *testing.T) {
+	a := strings.Split("a b c a b b a", " ")
+	b := strings.Split("c b a b a c", " ")
+	ops := diffLines(a, b)
+	var from, to []string
+	edits := 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			from = append(from, op.line)
+		}
+		if op.kind != '-' {
+			to = append(to, op.line)
+		}
+		if op.kind != ' ' {
+			edits++
+		}
+	}
+	if strings.Join(from, " ") != strings.Join(a, " ") || strings.Join(to, " ") != strings.Join(b, " ") {
+		t.Fatalf("edit script does not turn a into b: %v", ops)
+	}
+	if edits != 5 {
+		t.Errorf("expected the shortest edit script of 5 edits, got %d: %v", edits, ops)
+	}
+}
+
+func TestUnifiedDiff_NewFile(t *testing.T) {
+	got := unifiedDiff("/dev/null", "b/new.go", nil, []byte("package api\n"))
+	want := "--- /dev/null\n+++ b/new.go\n@@ -0,0 +1,1 @@\n+package api\n"
+	if got != want {
+		t.Errorf("expected diff\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestCheckFiles(t *testing.T) {
+	tmp := t.TempDir()
+	restore := chdir(t, tmp)
+	defer restore()
+
+	pet := filepath.Join("gen", "models", "pet_model.go")
+	store := filepath.Join("gen", "api", "store", "store_api.go")
+	mustWriteFile(t, pet, []byte("package models\n"))
+	mustWriteFile(t, store, []byte("package store\n"))
+	if err := updateManifest("gen", []string{pet, store}, false); err != nil {
+		t.Fatalf("updateManifest: %v", err)
+	}
+
+	mem := newMemorySink()
+	if err := mem.WriteFile(pet, []byte("package models\n")); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	var out strings.Builder
+	outdated, err := checkFiles(&out, "gen", mem)
+	if err != nil {
+		t.Fatalf("checkFiles: %v", err)
+	}
+	if outdated != 1 || !strings.Contains(out.String(), "stale gen/api/store/store_api.go is no longer generated") {
+		t.Errorf("expected the store API to be reported stale, got %d:\n%s", outdated, out.String())
+	}
+
+	user := filepath.Join("gen", "api", "user", "user_api.go")
+	mem = newMemorySink()
+	for path, data := range map[string]string{pet: "package models\n\n// changed\n", store: "package store\n", user: "package user\n"} {
+		if err := mem.WriteFile(path, []byte(data)); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	out.Reset()
+	if outdated, err = checkFiles(&out, "gen", mem); err != nil {
+		t.Fatalf("checkFiles: %v", err)
+	}
+	if outdated != 2 {
+		t.Errorf("expected 2 files out of date, got %d:\n%s", outdated, out.String())
+	}
+	for _, want := range []string{"+++ b/gen/models/pet_model.go", "+// changed", "--- /dev/null\n+++ b/gen/api/user/user_api.go"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in\n%s", want, out.String())
+		}
+	}
+	if mustRead(t, pet) != "package models\n" {
+		t.Errorf("checkFiles must not write files")
+	}
+
+	// A run keeps the edited stale file in the manifest, check tells how to
+	// get rid of it.
+	mustWriteFile(t, store, []byte("package store\n\n// edited\n"))
+	mem = newMemorySink()
+	if err := mem.WriteFile(pet, []byte("package models\n")); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	out.Reset()
+	if outdated, err = checkFiles(&out, "gen", mem); err != nil {
+		t.Fatalf("checkFiles: %v", err)
+	}
+	want := "stale, modified gen/api/store/store_api.go is no longer generated and was edited since generation; run with --force to remove it\n"
+	if outdated != 1 || out.String() != want {
+		t.Errorf("expected the edited store API to be reported stale and modified, got %d:\n%s", outdated, out.String())
+	}
+}
@@ -0,0 +1,196 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around changes.
+const diffContext = 3
+
+// maxEditDistance bounds the work of the line diff. Files further apart
+// are shown as entirely replaced.
+const maxEditDistance = 4000
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// unifiedDiff renders the line changes from a to b as a unified diff, or
+// nothing when they are equal.
+func unifiedDiff(fromName, toName string, a, b []byte) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+	var changes []int
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for i := 0; i < len(changes); {
+		start := max(changes[i]-diffContext, 0)
+		end := changes[i] + 1
+		for i++; i < len(changes) && changes[i]-end < 2*diffContext; i++ {
+			end = changes[i] + 1
+		}
+		end = min(end+diffContext, len(ops))
+
+		fromStart, toStart := 0, 0
+		for _, op := range ops[:start] {
+			if op.kind != '+' {
+				fromStart++
+			}
+			if op.kind != '-' {
+				toStart++
+			}
+		}
+		fromLen, toLen := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				fromLen++
+			}
+			if op.kind != '-' {
+				toLen++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(fromStart, fromLen), hunkRange(toStart, toLen))
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	return out.String()
+}
+
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a shortest edit script from a to b with the Myers
+// algorithm, after trimming their common prefix and suffix.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+func myers(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		if d > maxEditDistance {
+			return replaceAll(a, b)
+		}
+		// Step d only reads the diagonals next to -d..d, so only those are
+		// kept for the backtrack rather than all of v.
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack walks the trace of myers back from the end of a and b. The
+// trace of step d holds the diagonals -d-1..d+1, the diagonal k is at k+d+1.
+func backtrack(a, b []string, trace [][]int) []diffOp {
+	var ops []diffOp
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		offset := d + 1
+		k := x - y
+		var prevK int
+		if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{' ', a[x]})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, diffOp{'+', b[y]})
+		} else {
+			x--
+			ops = append(ops, diffOp{'-', a[x]})
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+func replaceAll(a, b []string) []diffOp {
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a {
+		ops = append(ops, diffOp{'-', line})
+	}
+	for _, line := range b {
+		ops = append(ops, diffOp{'+', line})
+	}
+	return ops
+}
@@ -1,22 +1,48 @@
 package generator
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/iancoleman/strcase"
+	"go/token"
+	"go/types"
 	"gopenapi/internal/config"
 	"gopenapi/internal/mapper"
 	"gopenapi/internal/templates"
 	"gopenapi/internal/utils"
 	"log"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 )
 
 type Generator struct {
 	cfg *config.Config
+	// Force removes stale generated files even when they were edited since
+	// they were generated.
+	Force bool
+	// Check compares the generated files with those on disk instead of
+	// writing them, printing a diff of those out of date.
+	Check bool
+	// DryRun lists the files that would be generated and their sizes
+	// instead of writing them.
+	DryRun bool
+	// Stdout writes the generated files to the standard output instead of
+	// the output directory.
+	Stdout bool
+
+	// logPrefix names the target in the logs of a run generating several.
+	logPrefix string
 }
 
 func NewGenerator(cfg *config.Config) Generator {
@@ -25,47 +51,270 @@ func NewGenerator(cfg *config.Config) Generator {
 	}
 }
 
+// Generate generates the code and exits on failure.
 func (g Generator) Generate() {
-	loader := openapi3.NewLoader()
-	doc, err := loader.LoadFromFile(g.cfg.Input)
+	if err := g.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Run generates the code of every target, or checks or lists it depending
+// on the mode of the generator, and returns the first error met.
+func (g Generator) Run() error {
+	outputs, err := g.renderTargets()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case g.DryRun:
+		var count int
+		for _, out := range outputs {
+			for _, path := range out.mem.paths {
+				if err := (dryRunSink{os.Stdout}).WriteFile(path, out.mem.files[path]); err != nil {
+					return err
+				}
+			}
+			count += len(out.mem.paths)
+		}
+		log.Printf("%d files would be generated", count)
+		return nil
+	case g.Stdout:
+		stream := &streamSink{w: os.Stdout}
+		for _, out := range outputs {
+			for _, path := range out.mem.paths {
+				if err := stream.WriteFile(path, out.mem.files[path]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case g.Check:
+		var outdated int
+		for _, out := range outputs {
+			count, err := checkFiles(os.Stdout, out.dir, out.mem)
+			if err != nil {
+				return fmt.Errorf("failed to check generated files: %w", err)
+			}
+			outdated += count
+		}
+		if outdated > 0 {
+			return fmt.Errorf("%d generated files are out of date, run gopenapi generate", outdated)
+		}
+		log.Printf("Generated files are up to date")
+		return nil
+	}
+
+	for _, out := range outputs {
+		for _, cfg := range out.cfgs {
+			if err := createDir(cfg); err != nil {
+				return err
+			}
+		}
+		for _, path := range out.mem.paths {
+			if err := (diskSink{}).WriteFile(path, out.mem.files[path]); err != nil {
+				return fmt.Errorf("failed to write file %s: %w", path, err)
+			}
+		}
+		if err := updateManifest(out.dir, out.mem.paths, g.Force); err != nil {
+			return fmt.Errorf("failed to update manifest: %w", err)
+		}
+	}
+	return nil
+}
+
+// Render generates the code in memory and returns the content of every
+// file keyed by its path.
+func (g Generator) Render() (map[string][]byte, error) {
+	mem, err := g.renderMemory()
+	if err != nil {
+		return nil, err
+	}
+	return mem.files, nil
+}
+
+func (g Generator) renderMemory() (*memorySink, error) {
+	doc, models, apis, err := g.mapSpec()
+	if err != nil {
+		return nil, err
+	}
+	mem := newMemorySink()
+	if _, err := render(mem, doc, models, apis, g.cfg); err != nil {
+		return nil, err
+	}
+	return mem, nil
+}
+
+// output holds the files generated by the targets sharing an output
+// directory, which share its manifest too.
+type output struct {
+	dir  string
+	cfgs []*config.Config
+	mem  *memorySink
+}
+
+// renderTargets renders the targets of the config concurrently and groups
+// their files by output directory.
+func (g Generator) renderTargets() ([]*output, error) {
+	targets := g.cfg.Generations()
+	sinks := make([]*memorySink, len(targets))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gen := g
+			gen.cfg = target
+			if len(targets) > 1 {
+				gen.logPrefix = target.Name + ": "
+			}
+			sinks[i], errs[i] = gen.renderMemory()
+			if errs[i] != nil && len(targets) > 1 {
+				errs[i] = fmt.Errorf("target %s: %w", target.Name, errs[i])
+			}
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return mergeTargets(targets, sinks, g.cfg.SharedModels)
+}
+
+// mergeTargets groups the files of the targets by output directory. Two
+// targets may only generate the same file when it is an identical model
+// file of the models directory they share and sharing is enabled.
+func mergeTargets(targets []*config.Config, sinks []*memorySink, shared bool) ([]*output, error) {
+	var outputs []*output
+	byDir := map[string]*output{}
+	// generated holds the first target generating each file, and what it
+	// generated.
+	type file struct {
+		target *config.Config
+		data   []byte
+	}
+	generated := map[string]file{}
+	for i, target := range targets {
+		baseOut := "."
+		if target.Output != "" {
+			baseOut = target.Output
+		}
+		dir := filepath.Clean(baseOut)
+		out, ok := byDir[dir]
+		if !ok {
+			out = &output{dir: baseOut, mem: newMemorySink()}
+			byDir[dir] = out
+			outputs = append(outputs, out)
+		}
+		out.cfgs = append(out.cfgs, target)
+
+		for _, path := range sinks[i].paths {
+			data := sinks[i].files[path]
+			prev, ok := generated[filepath.Clean(path)]
+			if !ok {
+				generated[filepath.Clean(path)] = file{target, data}
+				out.mem.WriteFile(path, data)
+				continue
+			}
+			first := prev.target
+			inModels := isModelsFile(path, first) && isModelsFile(path, target)
+			switch {
+			case inModels && !shared:
+				return nil, fmt.Errorf("targets %s and %s both generate %s, set sharedModels to share their models directory", first.Name, target.Name, path)
+			case !inModels:
+				return nil, fmt.Errorf("targets %s and %s both generate %s", first.Name, target.Name, path)
+			case !bytes.Equal(prev.data, data):
+				return nil, fmt.Errorf("targets %s and %s generate different versions of %s", first.Name, target.Name, path)
+			}
+		}
+	}
+	return outputs, nil
+}
+
+// isModelsFile reports whether path is in the models directory of cfg.
+func isModelsFile(path string, cfg *config.Config) bool {
+	baseOut := "."
+	if cfg.Output != "" {
+		baseOut = cfg.Output
+	}
+	return filepath.Dir(filepath.Clean(path)) == filepath.Join(baseOut, cfg.Packages.Models)
+}
+
+// mapSpec loads the input spec, logs its diagnostics and maps it to the
+// models and APIs to generate.
+func (g Generator) mapSpec() (*openapi3.T, []templates.Model, templates.APIs, error) {
+	doc, warnings, err := LoadSpec(g.cfg.Input, g.cfg.Overlays...)
 	if err != nil {
-		log.Fatalf("failed to load OpenAPI spec: %v", err)
+		return nil, nil, nil, fmt.Errorf("failed to load OpenAPI spec: %w", err)
+	}
+	warnings = append(warnings, mapper.FilterDocument(doc, g.cfg.Filter)...)
+	for _, diagnostic := range append(warnings, mapper.Diagnose(doc)...) {
+		log.Printf("%swarning: %s", g.logPrefix, diagnostic)
 	}
 
 	models := mapper.MapModelsFromSchemas(doc)
+	models = append(models, mapper.MapModelsFromPaths(doc)...)
 	apis := mapper.MapAPIFromPaths(doc)
+	models = mapper.ApplyReadWriteOnly(models, apis, g.cfg.Options.ReadWriteOnly)
+	mapper.ApplyRequiredOnUnmarshal(models)
+	mapper.ApplyStructTags(models, g.cfg.StructTags)
+	if g.cfg.Options.UnmarshalDefaults {
+		mapper.ApplyDefaultsOnUnmarshal(models)
+	}
+	return doc, models, apis, nil
+}
 
-	createDir(g.cfg)
-	renderModel(models, g.cfg)
-	renderAPI(apis, g.cfg)
+// render produces every generated file into dst and returns their paths.
+func render(dst sink, doc *openapi3.T, models []templates.Model, apis templates.APIs, cfg *config.Config) ([]string, error) {
+	steps := []func() ([]string, error){
+		func() ([]string, error) { return renderModel(dst, models, cfg) },
+		func() ([]string, error) { return renderModelValidation(dst, cfg) },
+		func() ([]string, error) { return renderModelReadWrite(dst, models, cfg) },
+		func() ([]string, error) { return renderAPI(dst, apis, cfg) },
+		func() ([]string, error) { return renderSecurity(dst, mapper.MapSecuritySchemes(doc), apis, cfg) },
+	}
+	if cfg.Options.GenerateValidation {
+		steps = append(steps, func() ([]string, error) { return renderValidation(dst, doc, cfg) })
+	}
+	if cfg.Options.GenerateClient {
+		steps = append(steps, func() ([]string, error) { return renderClient(dst, apis, cfg) })
+	}
+	var files []string
+	for _, step := range steps {
+		written, err := step()
+		files = append(files, written...)
+		if err != nil {
+			return files, err
+		}
+	}
+	return files, nil
 }
 
-func createDir(cfg *config.Config) {
+func createDir(cfg *config.Config) error {
 	// Determine base output directory; if empty, use current working directory
 	baseOut := "."
 	if cfg.Output != "" {
 		baseOut = cfg.Output
 		if err := os.MkdirAll(baseOut, os.ModePerm); err != nil {
-			log.Fatalf("failed to create output directory: %v", err)
-			return
+			return fmt.Errorf("failed to create output directory: %w", err)
 		}
 	}
 
 	modelsDir := filepath.Join(baseOut, cfg.Packages.Models)
 	if err := os.MkdirAll(modelsDir, os.ModePerm); err != nil {
-		log.Fatalf("failed to create output models directory: %v", err)
-		return
+		return fmt.Errorf("failed to create output models directory: %w", err)
 	}
 
 	apiDir := filepath.Join(baseOut, cfg.Packages.API)
 	if err := os.MkdirAll(apiDir, os.ModePerm); err != nil {
-		log.Fatalf("failed to create output api directory: %v", err)
-		return
+		return fmt.Errorf("failed to create output api directory: %w", err)
 	}
-
+	return nil
 }
 
-func renderModel(models []templates.Model, cfg *config.Config) {
+func renderModel(dst sink, models []templates.Model, cfg *config.Config) ([]string, error) {
+	var files []string
 	baseOut := "."
 	if cfg.Output != "" {
 		baseOut = cfg.Output
@@ -73,97 +322,520 @@ func renderModel(models []templates.Model, cfg *config.Config) {
 	for _, model := range models {
 		fileName := strcase.ToSnake(model.Name) + cfg.FileNaming.ModelSuffix
 		filePath := filepath.Join(baseOut, cfg.Packages.Models, fileName)
-		renderTemplate("internal/templates/model.tmpl", filePath, model)
-		log.Printf("Generated %s", filePath)
+		data := struct {
+			Package string
+			templates.Model
+		}{
+			Package: packageName(cfg.Packages.Models),
+			Model:   model,
+		}
+		if err := renderTemplate(dst, filepath.Join(templateDir, "model.tmpl"), filePath, data); err != nil {
+			return files, err
+		}
+		files = append(files, filePath)
 	}
+	return files, nil
 }
 
-func renderAPI(apis templates.APIs, cfg *config.Config) {
-	moduleName, err := getModuleName()
-	if err != nil {
-		log.Fatalf("failed to read module name: %v", err)
+// renderModelValidation writes the helpers shared by the Validate methods
+// of every model.
+func renderModelValidation(dst sink, cfg *config.Config) ([]string, error) {
+	baseOut := "."
+	if cfg.Output != "" {
+		baseOut = cfg.Output
+	}
+	filePath := filepath.Join(baseOut, cfg.Packages.Models, "validation.go")
+	data := struct {
+		Package string
+	}{
+		Package: packageName(cfg.Packages.Models),
+	}
+	if err := renderTemplate(dst, filepath.Join(templateDir, "model_validation.tmpl"), filePath, data); err != nil {
+		return nil, err
+	}
+	return []string{filePath}, nil
+}
+
+// renderModelReadWrite writes the helpers leaving the writeOnly fields out
+// of responses and the readOnly fields out of requests, when a model has any.
+func renderModelReadWrite(dst sink, models []templates.Model, cfg *config.Config) ([]string, error) {
+	if !slices.ContainsFunc(models, func(m templates.Model) bool { return m.OmitWriteOnly || m.IgnoreReadOnly }) {
+		return nil, nil
 	}
 	baseOut := "."
 	if cfg.Output != "" {
 		baseOut = cfg.Output
 	}
+	filePath := filepath.Join(baseOut, cfg.Packages.Models, "readwrite.go")
+	data := struct {
+		Package string
+	}{
+		Package: packageName(cfg.Packages.Models),
+	}
+	if err := renderTemplate(dst, filepath.Join(templateDir, "model_readwrite.tmpl"), filePath, data); err != nil {
+		return nil, err
+	}
+	return []string{filePath}, nil
+}
 
-	for tag, api := range apis {
-		fileName := strcase.ToSnake(tag) + cfg.FileNaming.APISuffix
-		filePath := filepath.Join(baseOut, cfg.Packages.API, fileName)
+func renderAPI(dst sink, apis templates.APIs, cfg *config.Config) ([]string, error) {
+	var files []string
+	baseOut := "."
+	if cfg.Output != "" {
+		baseOut = cfg.Output
+	}
+	modelPath, err := importPath(filepath.Join(baseOut, cfg.Packages.Models), cfg.Module)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute models import path: %w", err)
+	}
 
-		modelPath := moduleName + "/" + cfg.Packages.Models
-		if cfg.Output != "" {
-			modelPath = moduleName + "/" + cfg.Output + "/" + cfg.Packages.Models
-		}
+	for _, tag := range slices.Sorted(maps.Keys(apis)) {
+		api := apis[tag]
+		fileName := strcase.ToSnake(tag) + cfg.FileNaming.APISuffix
+		apiDir := apiPackageDir(tag, cfg)
+		filePath := filepath.Join(baseOut, apiDir, fileName)
 
 		data := struct {
+			Package    string
 			Tag        string
 			APIs       []templates.API
 			ModelsPath string
+			Imports    []string
+			Secured    bool
 		}{
+			Package:    packageName(apiDir),
 			Tag:        utils.CapitalizeFirstWord(tag),
 			APIs:       api,
 			ModelsPath: modelPath,
+			Imports:    apiImports(api, modelsImport(modelPath)),
+			Secured:    isSecured(api),
 		}
 
-		renderTemplate("internal/templates/api.tmpl", filePath, data)
-		log.Printf("Generated %s", filePath)
+		if err := renderTemplate(dst, filepath.Join(templateDir, "api.tmpl"), filePath, data); err != nil {
+			return files, err
+		}
+		files = append(files, filePath)
 	}
+	return files, nil
+}
 
+// renderSecurity writes the security schemes, authentication middleware and
+// client credentials shared by every API file, once per secured API package
+// in the tag layout. Nothing is written when the spec declares no security
+// schemes.
+func renderSecurity(dst sink, schemes []templates.SecurityScheme, apis templates.APIs, cfg *config.Config) ([]string, error) {
+	if len(schemes) == 0 {
+		return nil, nil
+	}
+	var files []string
+	baseOut := "."
+	if cfg.Output != "" {
+		baseOut = cfg.Output
+	}
+	dirs := []string{cfg.Packages.API}
+	if cfg.Options.APILayout == "tag" {
+		dirs = nil
+		for tag, api := range apis {
+			if isSecured(api) {
+				dirs = append(dirs, apiPackageDir(tag, cfg))
+			}
+		}
+		sort.Strings(dirs)
+	}
+	for _, dir := range dirs {
+		filePath := filepath.Join(baseOut, dir, "security.go")
+		data := struct {
+			Package string
+			Schemes []templates.SecurityScheme
+		}{
+			Package: packageName(dir),
+			Schemes: schemes,
+		}
+		if err := renderTemplate(dst, filepath.Join(templateDir, "security.tmpl"), filePath, data); err != nil {
+			return files, err
+		}
+		files = append(files, filePath)
+	}
+	return files, nil
 }
 
-func getModuleName() (string, error) {
-	data, err := os.ReadFile("go.mod")
+// renderClient writes the client shared by the API files of every API
+// package, and the methods calling the operations of each tag next to its
+// handlers.
+func renderClient(dst sink, apis templates.APIs, cfg *config.Config) ([]string, error) {
+	var files []string
+	baseOut := "."
+	if cfg.Output != "" {
+		baseOut = cfg.Output
+	}
+	modelPath, err := importPath(filepath.Join(baseOut, cfg.Packages.Models), cfg.Module)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute models import path: %w", err)
+	}
+
+	dirs := map[string]bool{}
+	for _, tag := range slices.Sorted(maps.Keys(apis)) {
+		api := apis[tag]
+		apiDir := apiPackageDir(tag, cfg)
+		dirs[apiDir] = true
+		filePath := filepath.Join(baseOut, apiDir, strcase.ToSnake(tag)+"_client.go")
+		data := struct {
+			Package string
+			APIs    []templates.API
+			Imports []string
+		}{
+			Package: packageName(apiDir),
+			APIs:    api,
+			Imports: clientImports(api, modelsImport(modelPath)),
+		}
+		if err := renderTemplate(dst, filepath.Join(templateDir, "api_client.tmpl"), filePath, data); err != nil {
+			return files, err
+		}
+		files = append(files, filePath)
+	}
+	for _, dir := range slices.Sorted(maps.Keys(dirs)) {
+		filePath := filepath.Join(baseOut, dir, "client.go")
+		data := struct {
+			Package string
+		}{
+			Package: packageName(dir),
+		}
+		if err := renderTemplate(dst, filepath.Join(templateDir, "client.tmpl"), filePath, data); err != nil {
+			return files, err
+		}
+		files = append(files, filePath)
+	}
+	return files, nil
+}
+
+// renderValidation embeds the spec into the API package and writes the
+// request validation middleware. LoadSpec already internalized the
+// references to other files, so doc is embedded as is and left untouched.
+func renderValidation(dst sink, doc *openapi3.T, cfg *config.Config) ([]string, error) {
+	baseOut := "."
+	if cfg.Output != "" {
+		baseOut = cfg.Output
+	}
+	const specFile = "openapi.json"
+	spec, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal OpenAPI spec: %w", err)
+	}
+	specPath := filepath.Join(baseOut, cfg.Packages.API, specFile)
+	if err := dst.WriteFile(specPath, spec); err != nil {
+		return nil, fmt.Errorf("failed to write file %s: %w", specPath, err)
+	}
+
+	filePath := filepath.Join(baseOut, cfg.Packages.API, "validation.go")
+	data := struct {
+		Package  string
+		SpecFile string
+	}{
+		Package:  packageName(cfg.Packages.API),
+		SpecFile: specFile,
+	}
+	if err := renderTemplate(dst, filepath.Join(templateDir, "validation.tmpl"), filePath, data); err != nil {
+		return []string{specPath}, err
+	}
+	return []string{specPath, filePath}, nil
+}
+
+// apiPackageDir returns the directory of the API package of a tag, relative
+// to the output directory.
+func apiPackageDir(tag string, cfg *config.Config) string {
+	if cfg.Options.APILayout == "tag" {
+		return filepath.Join(cfg.Packages.API, strcase.ToSnake(tag))
+	}
+	return cfg.Packages.API
+}
+
+// packageName derives the package clause of a directory from its last
+// element, dropping the characters Go identifiers cannot hold. Names that
+// are not identifiers or that shadow a predeclared one get a "pkg" prefix.
+func packageName(dir string) string {
+	name := strings.ToLower(path.Base(strings.ReplaceAll(dir, `\`, "/")))
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return -1
+	}, name)
+	if name == "" || name[0] >= '0' && name[0] <= '9' || token.IsKeyword(name) || types.Universe.Lookup(name) != nil {
+		name = "pkg" + name
+	}
+	return name
+}
+
+// modelsImport imports the models package under the name "models" the API
+// templates qualify model types with.
+func modelsImport(modelsPath string) string {
+	if packageName(modelsPath) == "models" {
+		return modelsPath
+	}
+	return "models " + modelsPath
+}
+
+func isSecured(apis []templates.API) bool {
+	for _, api := range apis {
+		if len(api.Security) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// apiImports returns the packages referenced by the generated handlers and
+// response types of a single API file.
+func apiImports(apis []templates.API, modelsPath string) []string {
+	var usesFmt, usesIO, usesMultipart, usesHTTP, usesBinding, usesModels bool
+	for _, api := range apis {
+		if body := api.RequestBody; body != nil {
+			usesHTTP = usesHTTP || body.Binding != "binary"
+			usesIO = usesIO || body.Binding == "binary" || body.Binding == "text"
+			usesModels = usesModels || isModelType(body.ModelName)
+			for _, field := range body.FormFields {
+				usesMultipart = usesMultipart || field.IsFile
+			}
+		}
+		if api.Response == nil || len(api.Parameters) > 0 {
+			usesHTTP = true
+		}
+		for _, param := range api.Parameters {
+			usesModels = usesModels || isModelType(param.GoType)
+			usesBinding = usesBinding || param.In == "cookie"
+		}
+		for _, resp := range api.Responses {
+			usesModels = usesModels || isModelType(resp.ModelName)
+			if len(resp.Headers) > 0 {
+				usesFmt = true
+			}
+		}
+	}
+	var imports []string
+	for _, api := range apis {
+		for _, imp := range api.Imports {
+			if !slices.Contains(imports, imp) {
+				imports = append(imports, imp)
+			}
+		}
+	}
+	sort.Strings(imports)
+	if usesFmt {
+		imports = append(imports, "fmt")
+	}
+	if usesIO {
+		imports = append(imports, "io")
+	}
+	if usesMultipart {
+		imports = append(imports, "mime/multipart")
+	}
+	if usesHTTP {
+		imports = append(imports, "net/http")
+	}
+	if usesBinding {
+		imports = append(imports, "github.com/gin-gonic/gin/binding")
+	}
+	if usesModels {
+		imports = append(imports, modelsPath)
+	}
+	return imports
+}
+
+// clientImports returns the packages referenced by the client methods of a
+// single API file: only request bodies name types there.
+func clientImports(apis []templates.API, modelsPath string) []string {
+	var usesIO, usesModels bool
+	var imports []string
+	for _, api := range apis {
+		body := api.RequestBody
+		if body == nil {
+			continue
+		}
+		switch {
+		case body.Encoding == "binary":
+			usesIO = true
+		case body.Encoding == "text":
+		default:
+			usesModels = usesModels || isModelType(body.ModelName)
+			for _, imp := range api.Imports {
+				pkg := path.Base(imp)
+				if alias, _, ok := strings.Cut(imp, " "); ok {
+					pkg = alias
+				}
+				if strings.Contains(body.ModelName, pkg+".") && !slices.Contains(imports, imp) {
+					imports = append(imports, imp)
+				}
+			}
+		}
+	}
+	sort.Strings(imports)
+	if usesIO {
+		imports = append(imports, "io")
+	}
+	if usesModels {
+		imports = append(imports, modelsPath)
+	}
+	return imports
+}
+
+// hasResponse reports whether any of responses has the given status.
+func hasResponse(responses []templates.Response, status string) bool {
+	return slices.ContainsFunc(responses, func(r templates.Response) bool { return r.Status == status })
+}
+
+// hasFile reports whether any of fields is a file upload.
+func hasFile(fields []templates.FormField) bool {
+	return slices.ContainsFunc(fields, func(f templates.FormField) bool { return f.IsFile })
+}
+
+// hasParam reports whether any of params is located in the given place.
+func hasParam(params []templates.Parameter, in string) bool {
+	for _, param := range params {
+		if param.In == in {
+			return true
+		}
+	}
+	return false
+}
+
+// qualifyModel prefixes the named type in a Go type expression produced by
+// the mapper with the models package, e.g. []Pet becomes []models.Pet.
+// Builtin types are left untouched.
+func qualifyModel(goType string) string {
+	if !isModelType(goType) {
+		return goType
+	}
+	i := strings.LastIndexAny(goType, "]*") + 1
+	return goType[:i] + "models." + goType[i:]
+}
+
+// isModelType reports whether goType refers to a type in the models package,
+// neither predeclared nor qualified by another package.
+func isModelType(goType string) bool {
+	name := goType[strings.LastIndexAny(goType, "]*")+1:]
+	if name == "" || name == "interface{}" || types.Universe.Lookup(name) != nil {
+		return false
+	}
+	return !strings.Contains(name, ".")
+}
+
+// importSpec renders an import given as "path" or "alias path".
+func importSpec(imp string) string {
+	if alias, path, ok := strings.Cut(imp, " "); ok {
+		return alias + " " + strconv.Quote(path)
+	}
+	return strconv.Quote(imp)
+}
+
+// importPath returns the import path of the package in dir. The module is
+// the nearest go.mod above dir, its module path can be overridden by
+// module. Without a go.mod above dir, dir must lie in the module of the
+// working directory, or in the working directory itself for an overridden
+// module.
+func importPath(dir, module string) (string, error) {
+	abs, err := filepath.Abs(filepath.FromSlash(strings.ReplaceAll(dir, `\`, "/")))
 	if err != nil {
 		return "", err
 	}
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, "module ") {
-			return strings.TrimSpace(strings.TrimPrefix(line, "module ")), nil
+	root, modulePath, err := findModule(abs)
+	if err != nil {
+		wd, wdErr := os.Getwd()
+		if wdErr != nil {
+			return "", wdErr
+		}
+		if wdRoot, wdModule, wdErr := findModule(wd); wdErr == nil {
+			root, modulePath = wdRoot, wdModule
+		} else if module == "" {
+			return "", err
+		} else {
+			root = wd
+		}
+	}
+	if module != "" {
+		modulePath = module
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return "", err
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("%s is outside of module %s in %s", dir, modulePath, root)
+	}
+	if rel == "." {
+		return modulePath, nil
+	}
+	return path.Join(modulePath, rel), nil
+}
+
+// findModule walks up from dir to the nearest go.mod and returns its
+// directory and module path.
+func findModule(dir string) (string, string, error) {
+	for current := dir; ; {
+		data, err := os.ReadFile(filepath.Join(current, "go.mod"))
+		if err == nil {
+			modulePath, err := modulePath(data)
+			return current, modulePath, err
+		}
+		if !os.IsNotExist(err) {
+			return "", "", err
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", "", fmt.Errorf("no go.mod found above %s", dir)
+		}
+		current = parent
+	}
+}
+
+func modulePath(goMod []byte) (string, error) {
+	for _, line := range strings.Split(string(goMod), "\n") {
+		line = strings.TrimSpace(line)
+		if rest, ok := strings.CutPrefix(line, "module"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			rest, _, _ = strings.Cut(rest, "//")
+			name := strings.Trim(strings.TrimSpace(rest), `"`+"`")
+			if name != "" {
+				return name, nil
+			}
 		}
 	}
 	return "", fmt.Errorf("module name not found in go.mod")
 }
 
-func renderTemplate(path, out string, data any) {
+// templateDir holds the templates, read relative to the working directory.
+const templateDir = "internal/templates"
+
+func renderTemplate(dst sink, path, out string, data any) error {
 	tmplContent, err := os.ReadFile(path)
 	if err != nil {
-		log.Fatalf("failed to read template %s: %v", path, err)
+		return fmt.Errorf("failed to read template %s: %w", path, err)
 	}
 
 	// Useful helpers for templates
 	funcs := template.FuncMap{
-		"upper":  strings.ToUpper,
-		"lower":  strings.ToLower,
-		"snake":  strcase.ToSnake,
-		"camel":  strcase.ToLowerCamel,
-		"pascal": strcase.ToCamel,
+		"upper":       strings.ToUpper,
+		"lower":       strings.ToLower,
+		"snake":       strcase.ToSnake,
+		"camel":       strcase.ToLowerCamel,
+		"pascal":      strcase.ToCamel,
+		"model":       qualifyModel,
+		"importSpec":  importSpec,
+		"hasParam":    hasParam,
+		"hasResponse": hasResponse,
+		"hasFile":     hasFile,
 	}
 
 	tmpl, err := template.New("").Funcs(funcs).Parse(string(tmplContent))
 	if err != nil {
-		log.Fatalf("failed to parse template %s: %v", path, err)
+		return fmt.Errorf("failed to parse template %s: %w", path, err)
 	}
 
-	// Ensure parent directory exists
-	if err := os.MkdirAll(filepath.Dir(out), os.ModePerm); err != nil {
-		log.Fatalf("failed to ensure output directory for %s: %v", out, err)
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("failed to execute template %s: %w", path, err)
 	}
-
-	f, err := os.Create(out)
-	if err != nil {
-		log.Fatalf("failed to create file %s: %v", out, err)
-	}
-	defer func(f *os.File) {
-		if cerr := f.Close(); cerr != nil {
-			log.Fatalf("failed to close file %s: %v", out, cerr)
-		}
-	}(f)
-
-	if err := tmpl.Execute(f, data); err != nil {
-		log.Fatalf("failed to execute template %s: %v", path, err)
+	if err := dst.WriteFile(out, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", out, err)
 	}
-
+	return nil
 }
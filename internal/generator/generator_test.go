@@ -2,12 +2,16 @@ package generator
 
 import (
 	"bytes"
+	"fmt"
+	"go/parser"
+	"go/token"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"gopenapi/internal/config"
 	"gopenapi/internal/templates"
 )
@@ -20,30 +24,97 @@ func TestNewGenerator(t *testing.T) {
 	}
 }
 
-func TestGetModuleName_Success(t *testing.T) {
+func TestFindModule_Success(t *testing.T) {
+	tmp := t.TempDir()
+	mod := "module example.com/awesome // the app\n\ngo 1.24\n"
+	mustWriteFile(t, filepath.Join(tmp, "go.mod"), []byte(mod))
+	nested := filepath.Join(tmp, "gen", "models")
+
+	root, got, err := findModule(nested)
+	if err != nil {
+		t.Fatalf("findModule returned error: %v", err)
+	}
+	if root != tmp || got != "example.com/awesome" {
+		t.Fatalf("unexpected module: got %q in %q", got, root)
+	}
+}
+
+func TestFindModule_NoGoMod(t *testing.T) {
+	tmp := t.TempDir()
+	if _, _, err := findModule(tmp); err == nil {
+		t.Fatalf("expected error when go.mod is missing")
+	}
+}
+
+func TestImportPath(t *testing.T) {
 	tmp := t.TempDir()
 	restore := chdir(t, tmp)
 	defer restore()
+	mustWriteFile(t, filepath.Join(tmp, "go.mod"), []byte("module example.com/awesome"))
+	mustWriteFile(t, filepath.Join(tmp, "nested", "go.mod"), []byte(`module "example.com/nested"`))
+
+	tests := []struct {
+		dir, module, want string
+	}{
+		{dir: "models", want: "example.com/awesome/models"},
+		{dir: "./gen/models", want: "example.com/awesome/gen/models"},
+		{dir: `gen\internal\models`, want: "example.com/awesome/gen/internal/models"},
+		{dir: filepath.Join(tmp, "gen", "models"), want: "example.com/awesome/gen/models"},
+		{dir: "nested/models", want: "example.com/nested/models"},
+		{dir: "nested", want: "example.com/nested"},
+		{dir: "gen/models", module: "example.com/override", want: "example.com/override/gen/models"},
+	}
+	for _, tt := range tests {
+		got, err := importPath(tt.dir, tt.module)
+		if err != nil {
+			t.Errorf("importPath(%q, %q) returned error: %v", tt.dir, tt.module, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("importPath(%q, %q) = %q, want %q", tt.dir, tt.module, got, tt.want)
+		}
+	}
 
-	mod := "module example.com/awesome"
-	mustWriteFile(t, filepath.Join(tmp, "go.mod"), []byte(mod))
+	if _, err := importPath(filepath.Join(tmp, ".."), ""); err == nil {
+		t.Errorf("expected error for a directory outside of the module")
+	}
+}
 
-	got, err := getModuleName()
+func TestImportPath_OutsideModule(t *testing.T) {
+	tmp := t.TempDir()
+	project := filepath.Join(tmp, "project")
+	mustWriteFile(t, filepath.Join(project, "go.mod"), []byte("module example.com/awesome"))
+	restore := chdir(t, project)
+	defer restore()
+	root, err := os.Getwd()
 	if err != nil {
-		t.Fatalf("getModuleName returned error: %v", err)
+		t.Fatal(err)
 	}
-	if got != "example.com/awesome" {
-		t.Fatalf("unexpected module name: got %q", got)
+
+	for _, module := range []string{"", "example.com/override"} {
+		_, err := importPath("../gen/models", module)
+		modulePath := module
+		if modulePath == "" {
+			modulePath = "example.com/awesome"
+		}
+		want := fmt.Sprintf("../gen/models is outside of module %s in %s", modulePath, root)
+		if err == nil || err.Error() != want {
+			t.Errorf("importPath with module %q: expected error %q, got %v", module, want, err)
+		}
 	}
 }
 
-func TestGetModuleName_NoGoMod(t *testing.T) {
+func TestImportPath_ModuleWithoutGoMod(t *testing.T) {
 	tmp := t.TempDir()
 	restore := chdir(t, tmp)
 	defer restore()
 
-	if _, err := getModuleName(); err == nil {
-		t.Fatalf("expected error when go.mod is missing")
+	if _, err := importPath("gen/models", ""); err == nil {
+		t.Fatalf("expected error without go.mod and module")
+	}
+	got, err := importPath("gen/models", "example.com/app")
+	if err != nil || got != "example.com/app/gen/models" {
+		t.Fatalf("expected example.com/app/gen/models, got %q (%v)", got, err)
 	}
 }
 
@@ -61,7 +132,9 @@ func TestRenderTemplate_WritesAndSupportsFuncs(t *testing.T) {
 	// Render to file
 	out := filepath.Join(tmp, "out.txt")
 	data := struct{ Name string }{Name: "MyName"}
-	renderTemplate(tmplPath, out, data)
+	if err := renderTemplate(diskSink{}, tmplPath, out, data); err != nil {
+		t.Fatalf("renderTemplate: %v", err)
+	}
 
 	content := mustRead(t, out)
 	for _, want := range []string{"HELLO", "my_name", "myName", "MyName"} {
@@ -80,7 +153,9 @@ func TestCreateDir_CreatesAll(t *testing.T) {
 			API:    "api",
 		},
 	}
-	createDir(cfg)
+	if err := createDir(cfg); err != nil {
+		t.Fatalf("createDir: %v", err)
+	}
 
 	if _, err := os.Stat(filepath.Join(tmp, "models")); err != nil {
 		t.Fatalf("models dir not created: %v", err)
@@ -111,14 +186,18 @@ func TestRenderAPI_WritesFile_WithAndWithoutOutput(t *testing.T) {
 				APISuffix: "_api.go",
 			},
 		}
-		createDir(cfg)
+		if err := createDir(cfg); err != nil {
+			t.Fatalf("createDir: %v", err)
+		}
 
 		apis := templates.APIs{
 			"user": {
 				{OperationID: "GetUser", Method: "GET", Path: "/users/{id}"},
 			},
 		}
-		renderAPI(apis, cfg)
+		if _, err := renderAPI(diskSink{}, apis, cfg); err != nil {
+			t.Fatalf("renderAPI: %v", err)
+		}
 
 		// Expected file path for "user" tag
 		outFile := filepath.Join(tmp, "api", "user_api.go")
@@ -156,10 +235,14 @@ func TestRenderAPI_WritesFile_WithAndWithoutOutput(t *testing.T) {
 				APISuffix: "_api.go",
 			},
 		}
-		createDir(cfg)
+		if err := createDir(cfg); err != nil {
+			t.Fatalf("createDir: %v", err)
+		}
 
 		apis := templates.APIs{"user": {}}
-		renderAPI(apis, cfg)
+		if _, err := renderAPI(diskSink{}, apis, cfg); err != nil {
+			t.Fatalf("renderAPI: %v", err)
+		}
 
 		outFile := filepath.Join(tmp, "gen", "api", "user_api.go")
 		content := mustRead(t, outFile)
@@ -169,6 +252,59 @@ func TestRenderAPI_WritesFile_WithAndWithoutOutput(t *testing.T) {
 	})
 }
 
+func TestAPIImports(t *testing.T) {
+	apis := []templates.API{
+		{
+			OperationID: "LoginUser",
+			Responses: []templates.Response{
+				{Status: "200", ModelName: "Session", Headers: []templates.ResponseHeader{{Name: "X-Rate-Limit"}}},
+			},
+		},
+	}
+	apis[0].Response = &apis[0].Responses[0]
+
+	got := apiImports(apis, "example.com/awesome/models")
+	want := []string{"fmt", "example.com/awesome/models"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("apiImports = %v, want %v", got, want)
+	}
+
+	got = apiImports([]templates.API{{OperationID: "Ping"}}, "example.com/awesome/models")
+	if strings.Join(got, ",") != "net/http" {
+		t.Fatalf("apiImports without responses = %v, want [net/http]", got)
+	}
+}
+
+func TestQualifyModel(t *testing.T) {
+	tests := map[string]string{
+		"Pet":            "models.Pet",
+		"[]Pet":          "[]models.Pet",
+		"map[string]Pet": "map[string]models.Pet",
+		"[]string":       "[]string",
+		"map[string]int": "map[string]int",
+		"interface{}":    "interface{}",
+		"time.Time":      "time.Time",
+		"[]uint8":        "[]uint8",
+		"*rune":          "*rune",
+		"map[string]any": "map[string]any",
+		"error":          "error",
+	}
+	for in, want := range tests {
+		if got := qualifyModel(in); got != want {
+			t.Errorf("qualifyModel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestImportSpec(t *testing.T) {
+	if got := importSpec("time"); got != `"time"` {
+		t.Errorf("importSpec(time) = %s", got)
+	}
+	if got := importSpec("nip net/netip"); got != `nip "net/netip"` {
+		t.Errorf("importSpec(nip net/netip) = %s", got)
+	}
+}
+
 func TestRenderModel_WritesFile(t *testing.T) {
 	tmp := t.TempDir()
 	restore := chdir(t, tmp)
@@ -187,7 +323,9 @@ func TestRenderModel_WritesFile(t *testing.T) {
 			ModelSuffix: "_model.go",
 		},
 	}
-	createDir(cfg)
+	if err := createDir(cfg); err != nil {
+		t.Fatalf("createDir: %v", err)
+	}
 
 	// Use a model with Name so file name is deterministic: user_model.go
 	models := []templates.Model{
@@ -196,7 +334,9 @@ func TestRenderModel_WritesFile(t *testing.T) {
 			Name: "User",
 		},
 	}
-	renderModel(models, cfg)
+	if _, err := renderModel(diskSink{}, models, cfg); err != nil {
+		t.Fatalf("renderModel: %v", err)
+	}
 
 	outFile := filepath.Join(tmp, "models", "user_model.go")
 	if _, err := os.Stat(outFile); err != nil {
@@ -204,6 +344,205 @@ func TestRenderModel_WritesFile(t *testing.T) {
 	}
 }
 
+func TestRenderValidation_EmbedsSpec(t *testing.T) {
+	tmp := t.TempDir()
+	restore := chdir(t, tmp)
+	defer restore()
+
+	mustWriteFile(t, filepath.Join(tmp, "internal", "templates", "validation.tmpl"), []byte("embed={{.SpecFile}}"))
+
+	cfg := &config.Config{Packages: config.Package{Models: "models", API: "api"}}
+	if err := createDir(cfg); err != nil {
+		t.Fatalf("createDir: %v", err)
+	}
+
+	doc := &openapi3.T{OpenAPI: "3.0.0", Info: &openapi3.Info{Title: "t", Version: "1"}, Paths: openapi3.NewPaths()}
+	if _, err := renderValidation(diskSink{}, doc, cfg); err != nil {
+		t.Fatalf("renderValidation: %v", err)
+	}
+
+	if content := mustRead(t, filepath.Join(tmp, "api", "validation.go")); content != "embed=openapi.json" {
+		t.Fatalf("unexpected validation.go content: %q", content)
+	}
+	spec := mustRead(t, filepath.Join(tmp, "api", "openapi.json"))
+	if !strings.Contains(spec, `"openapi": "3.0.0"`) {
+		t.Fatalf("expected embedded spec to be JSON, got: %q", spec)
+	}
+}
+
+func TestRenderValidation_Template(t *testing.T) {
+	tmpl, err := os.ReadFile(filepath.Join("..", "templates", "validation.tmpl"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	tmp := t.TempDir()
+	restore := chdir(t, tmp)
+	defer restore()
+	mustWriteFile(t, filepath.Join(tmp, "internal", "templates", "validation.tmpl"), tmpl)
+
+	cfg := &config.Config{Packages: config.Package{Models: "models", API: "api/v1"}}
+	doc := &openapi3.T{OpenAPI: "3.0.0", Info: &openapi3.Info{Title: "t", Version: "1"}, Paths: openapi3.NewPaths()}
+	mem := newMemorySink()
+	if _, err := renderValidation(mem, doc, cfg); err != nil {
+		t.Fatalf("renderValidation: %v", err)
+	}
+	src := mem.files[filepath.Join("api", "v1", "validation.go")]
+	file, err := parser.ParseFile(token.NewFileSet(), "validation.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("generated validation.go does not parse: %v\n%s", err, src)
+	}
+	if file.Name.Name != "v1" {
+		t.Errorf("expected package v1, got %s", file.Name.Name)
+	}
+	if !bytes.Contains(src, []byte("//go:embed openapi.json")) {
+		t.Errorf("expected the spec to be embedded, got:\n%s", src)
+	}
+}
+
+// clientTemplates copies the client templates into a module in a temporary
+// directory and moves there, returning the function moving back.
+func clientTemplates(t *testing.T) func() {
+	t.Helper()
+	tmp := t.TempDir()
+	for _, name := range []string{"client.tmpl", "api_client.tmpl"} {
+		tmpl, err := os.ReadFile(filepath.Join("..", "templates", name))
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		mustWriteFile(t, filepath.Join(tmp, "internal", "templates", name), tmpl)
+	}
+	mustWriteFile(t, filepath.Join(tmp, "go.mod"), []byte("module example.com/awesome"))
+	return chdir(t, tmp)
+}
+
+func TestRenderClient_Templates(t *testing.T) {
+	restore := clientTemplates(t)
+	defer restore()
+
+	cfg := &config.Config{Packages: config.Package{Models: "models", API: "api"}}
+	apis := templates.APIs{
+		"pet": {{
+			OperationID: "GetPet", Method: "GET", Path: "/pets/:id",
+			Parameters: []templates.Parameter{{Name: "id", GoName: "Id", GoType: "int", In: "path"}},
+			Responses: []templates.Response{
+				{Status: "200", StatusCode: 200, TypeName: "GetPet200Response", ModelName: "Pet", Binding: "json"},
+				{Status: "4XX", TypeName: "GetPet4XXResponse", ModelName: "Error", Binding: "json"},
+				{Status: "default", TypeName: "GetPetDefaultResponse"},
+			},
+		}},
+		"store": {{
+			OperationID: "PlaceOrder", Method: "POST", Path: "/orders",
+			RequestBody: &templates.RequestBody{ModelName: "Order", ContentType: "application/json", Binding: "json", Encoding: "json"},
+			Responses:   []templates.Response{{Status: "201", StatusCode: 201, TypeName: "PlaceOrder201Response"}},
+		}},
+	}
+	mem := newMemorySink()
+	if _, err := renderClient(mem, apis, cfg); err != nil {
+		t.Fatalf("renderClient: %v", err)
+	}
+	if got := strings.Join(mem.paths, " "); got != strings.Join([]string{
+		filepath.Join("api", "pet_client.go"), filepath.Join("api", "store_client.go"), filepath.Join("api", "client.go"),
+	}, " ") {
+		t.Fatalf("expected one client file per tag and a shared client.go, got %s", got)
+	}
+	for path, src := range mem.files {
+		if _, err := parser.ParseFile(token.NewFileSet(), path, src, parser.ParseComments); err != nil {
+			t.Fatalf("generated %s does not parse: %v\n%s", path, err, src)
+		}
+	}
+	pet := string(mem.files[filepath.Join("api", "pet_client.go")])
+	for _, want := range []string{
+		"func (c *Client) GetPet(ctx context.Context, params GetPetParams) (*GetPetResult, error)",
+		"Status4XX *GetPet4XXResponse",
+		"case resp.StatusCode/100 == 4:",
+		"r := &GetPet4XXResponse{ StatusCode: resp.StatusCode }",
+		"result.Default = r",
+	} {
+		if !strings.Contains(pet, want) {
+			t.Errorf("expected pet_client.go to contain %q, got:\n%s", want, pet)
+		}
+	}
+	if strings.Contains(pet, "unexpectedStatus") {
+		t.Errorf("expected the default response to handle every other status, got:\n%s", pet)
+	}
+	store := string(mem.files[filepath.Join("api", "store_client.go")])
+	for _, want := range []string{
+		`"example.com/awesome/models"`,
+		"func (c *Client) PlaceOrder(ctx context.Context, body models.Order) (*PlaceOrderResult, error)",
+		`encodeBody("json", "application/json", body)`,
+		"return nil, unexpectedStatus(req, resp)",
+	} {
+		if !strings.Contains(store, want) {
+			t.Errorf("expected store_client.go to contain %q, got:\n%s", want, store)
+		}
+	}
+}
+
+func TestRenderClient_Uploads(t *testing.T) {
+	restore := clientTemplates(t)
+	defer restore()
+
+	cfg := &config.Config{Packages: config.Package{Models: "models", API: "api"}}
+	apis := templates.APIs{
+		"files": {{
+			OperationID: "Upload", Method: "POST", Path: "/upload",
+			RequestBody: &templates.RequestBody{
+				TypeName: "UploadForm", ContentType: "multipart/form-data", Binding: "bind", Encoding: "multipart",
+				FormFields: []templates.FormField{
+					{Name: "extras", GoName: "Extras", GoType: "[]*multipart.FileHeader", IsFile: true},
+					{Name: "file", GoName: "File", GoType: "*multipart.FileHeader", IsFile: true},
+					{Name: "note", GoName: "Note", GoType: "string"},
+				},
+			},
+			Responses: []templates.Response{{Status: "204", StatusCode: 204, TypeName: "Upload204Response"}},
+		}, {
+			OperationID: "Login", Method: "POST", Path: "/login",
+			RequestBody: &templates.RequestBody{
+				TypeName: "LoginForm", ContentType: "application/x-www-form-urlencoded", Binding: "bind", Encoding: "form",
+				FormFields: []templates.FormField{{Name: "user", GoName: "User", GoType: "string"}},
+			},
+			Responses: []templates.Response{{Status: "204", StatusCode: 204, TypeName: "Login204Response"}},
+		}, {
+			OperationID: "PutBlob", Method: "PUT", Path: "/blob",
+			RequestBody: &templates.RequestBody{ContentType: "application/octet-stream", Binding: "binary", Encoding: "binary"},
+			Responses:   []templates.Response{{Status: "204", StatusCode: 204, TypeName: "PutBlob204Response"}},
+		}},
+	}
+	mem := newMemorySink()
+	if _, err := renderClient(mem, apis, cfg); err != nil {
+		t.Fatalf("renderClient: %v", err)
+	}
+	path := filepath.Join("api", "files_client.go")
+	src := string(mem.files[path])
+	if _, err := parser.ParseFile(token.NewFileSet(), path, src, parser.ParseComments); err != nil {
+		t.Fatalf("generated %s does not parse: %v\n%s", path, err, src)
+	}
+	for _, want := range []string{
+		"Extras []FilePart `form:\"extras\"`",
+		"File FilePart `form:\"file\"`",
+		"Note string `form:\"note\"`",
+		"func (c *Client) Upload(ctx context.Context, body UploadFormUpload) (*UploadResult, error)",
+		`encodeBody("multipart", "multipart/form-data", body)`,
+		"func (c *Client) Login(ctx context.Context, body LoginForm) (*LoginResult, error)",
+		`encodeBody("form", "application/x-www-form-urlencoded", body)`,
+		"func (c *Client) PutBlob(ctx context.Context, body io.Reader) (*PutBlobResult, error)",
+		`"io"`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("expected files_client.go to contain %q, got:\n%s", want, src)
+		}
+	}
+	if strings.Contains(src, "LoginFormUpload") {
+		t.Errorf("expected forms without files to be sent as is, got:\n%s", src)
+	}
+	client := string(mem.files[filepath.Join("api", "client.go")])
+	for _, kind := range []string{"json", "xml", "form", "multipart", "text", "binary"} {
+		if !strings.Contains(client, fmt.Sprintf("case %q:", kind)) {
+			t.Errorf("expected encodeBody to handle %s bodies", kind)
+		}
+	}
+}
+
 func TestGenerator_Generate_MissingSpec_Exits(t *testing.T) {
 	// We need a subprocess since log.Fatalf calls os.Exit.
 	if os.Getenv("GEN_HELPER") == "1" {
@@ -225,6 +564,134 @@ func TestGenerator_Generate_MissingSpec_Exits(t *testing.T) {
 	}
 }
 
+func TestGenerator_Render(t *testing.T) {
+	tmp := t.TempDir()
+	restore := chdir(t, tmp)
+	defer restore()
+
+	for _, name := range []string{"model.tmpl", "model_validation.tmpl", "api.tmpl", "security.tmpl", "validation.tmpl"} {
+		mustWriteFile(t, filepath.Join("internal", "templates", name), []byte("package {{.Package}}\n"))
+	}
+	mustWriteFile(t, "go.mod", []byte("module example.com/app\n"))
+	mustWriteFile(t, "spec.yaml", []byte(`
+openapi: 3.0.0
+info: {title: t, version: "1"}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      tags: [pet]
+      responses:
+        "204": {description: ok}
+components:
+  schemas:
+    Pet: {type: object, properties: {name: {type: string}}}
+`))
+	cfg := &config.Config{
+		Input:      "spec.yaml",
+		Output:     "gen",
+		Packages:   config.Package{Models: "models", API: "api"},
+		FileNaming: config.FileNaming{APISuffix: "_api.go", ModelSuffix: "_model.go"},
+	}
+
+	files, err := NewGenerator(cfg).Render()
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	for path, want := range map[string]string{
+		filepath.Join("gen", "models", "pet_model.go"):  "package models\n",
+		filepath.Join("gen", "models", "validation.go"): "package models\n",
+		filepath.Join("gen", "api", "pet_api.go"):       "package api\n",
+	} {
+		if got := string(files[path]); got != want {
+			t.Errorf("expected %s to be %q, got %q", path, want, got)
+		}
+	}
+	if _, err := os.Stat("gen"); !os.IsNotExist(err) {
+		t.Errorf("expected Render not to write the output directory, got %v", err)
+	}
+}
+
+func TestGenerator_RunTargets(t *testing.T) {
+	tmp := t.TempDir()
+	restore := chdir(t, tmp)
+	defer restore()
+
+	for _, name := range []string{"model_validation.tmpl", "api.tmpl", "security.tmpl", "validation.tmpl"} {
+		mustWriteFile(t, filepath.Join("internal", "templates", name), []byte("package {{.Package}}\n"))
+	}
+	mustWriteFile(t, filepath.Join("internal", "templates", "model.tmpl"), []byte("package {{.Package}}\n{{range .Fields}}// {{.GoName}}\n{{end}}"))
+	mustWriteFile(t, "go.mod", []byte("module example.com/app\n"))
+	spec := func(tag, properties string) []byte {
+		return []byte(`
+openapi: 3.0.0
+info: {title: t, version: "1"}
+paths:
+  /` + tag + `:
+    get:
+      operationId: list` + tag + `
+      tags: [` + tag + `]
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/Pet'}
+components:
+  schemas:
+    Pet: {type: object, properties: {` + properties + `}}
+`)
+	}
+	mustWriteFile(t, "stores.yaml", spec("stores", "name: {type: string}"))
+	mustWriteFile(t, "vets.yaml", spec("vets", "name: {type: string}"))
+	target := func(name, api string) *config.Config {
+		return &config.Config{
+			Name:       name,
+			Input:      name + ".yaml",
+			Output:     "gen",
+			Packages:   config.Package{Models: "models", API: api},
+			FileNaming: config.FileNaming{APISuffix: "_api.go", ModelSuffix: "_model.go"},
+		}
+	}
+	cfg := &config.Config{Targets: []*config.Config{target("stores", "storeapi"), target("vets", "vetapi")}}
+
+	err := NewGenerator(cfg).Run()
+	if err == nil || !strings.Contains(err.Error(), "set sharedModels") {
+		t.Fatalf("expected the shared models directory to be rejected, got %v", err)
+	}
+
+	cfg.SharedModels = true
+	if err := NewGenerator(cfg).Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	for _, path := range []string{"models/pet_model.go", "storeapi/stores_api.go", "vetapi/vets_api.go"} {
+		if _, err := os.Stat(filepath.Join("gen", path)); err != nil {
+			t.Errorf("expected %s to be generated: %v", path, err)
+		}
+	}
+	m, err := readManifest(filepath.Join("gen", manifestFile))
+	if err != nil {
+		t.Fatalf("readManifest: %v", err)
+	}
+	if len(m.Files) != 4 {
+		t.Errorf("expected the manifest to list the files of both targets, got %v", m.Files)
+	}
+
+	mustWriteFile(t, "vets.yaml", spec("vets", "name: {type: string}, age: {type: integer}"))
+	err = NewGenerator(cfg).Run()
+	if err == nil || !strings.Contains(err.Error(), "different versions of "+filepath.Join("gen", "models", "pet_model.go")) {
+		t.Errorf("expected the differing models to conflict, got %v", err)
+	}
+
+	if err := os.Remove("vets.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	err = NewGenerator(cfg).Run()
+	if err == nil || !strings.Contains(err.Error(), "target vets:") {
+		t.Errorf("expected the error to name the failing target, got %v", err)
+	}
+}
+
 // --- Helpers ---
 
 func helperRunGenerate() {
@@ -284,3 +751,81 @@ func mustRead(t *testing.T, path string) string {
 	}
 	return string(b)
 }
+
+func TestPackageName(t *testing.T) {
+	tests := map[string]string{
+		"models":           "models",
+		"internal/dto":     "dto",
+		"http-api":         "httpapi",
+		`gen\Api.V2`:       "apiv2",
+		"api/pet_store":    "pet_store",
+		"2024":             "pkg2024",
+		"example.com/x/v1": "v1",
+		"api/default":      "pkgdefault",
+		"api/type":         "pkgtype",
+		"api/string":       "pkgstring",
+		"api/error":        "pkgerror",
+	}
+	for dir, want := range tests {
+		if got := packageName(dir); got != want {
+			t.Errorf("packageName(%q) = %q, want %q", dir, got, want)
+		}
+	}
+}
+
+func TestModelsImport(t *testing.T) {
+	if got := modelsImport("example.com/app/models"); got != "example.com/app/models" {
+		t.Errorf("expected the models package to be imported as is, got %q", got)
+	}
+	if got := modelsImport("example.com/app/internal/dto"); got != "models example.com/app/internal/dto" {
+		t.Errorf("expected the dto package to be imported as models, got %q", got)
+	}
+}
+
+func TestRenderAPI_TagLayout(t *testing.T) {
+	tmp := t.TempDir()
+	restore := chdir(t, tmp)
+	defer restore()
+
+	mustWriteFile(t, filepath.Join(tmp, "go.mod"), []byte("module example.com/awesome"))
+	tmplDir := filepath.Join(tmp, "internal", "templates")
+	mustMkdirAll(t, tmplDir)
+	mustWriteFile(t, filepath.Join(tmplDir, "api.tmpl"), []byte("package {{.Package}} imports={{.Imports}}"))
+	mustWriteFile(t, filepath.Join(tmplDir, "security.tmpl"), []byte("package {{.Package}}"))
+
+	cfg := &config.Config{
+		Packages:   config.Package{Models: "dto", API: "api"},
+		FileNaming: config.FileNaming{APISuffix: "_api.go"},
+		Options:    config.Option{APILayout: "tag"},
+	}
+	apis := templates.APIs{
+		"pet":     {{OperationID: "GetPet", RequestBody: &templates.RequestBody{ModelName: "Pet", Binding: "json"}, Security: []templates.SecurityRequirement{{Schemes: []templates.SecurityScopes{{Name: "api_key"}}}}}},
+		"store":   {{OperationID: "GetInventory", Security: []templates.SecurityRequirement{{Schemes: []templates.SecurityScopes{{Name: "api_key"}}}}}},
+		"default": {{OperationID: "Ping"}},
+	}
+	if _, err := renderAPI(diskSink{}, apis, cfg); err != nil {
+		t.Fatalf("renderAPI: %v", err)
+	}
+	if _, err := renderSecurity(diskSink{}, []templates.SecurityScheme{{Name: "api_key"}}, apis, cfg); err != nil {
+		t.Fatalf("renderSecurity: %v", err)
+	}
+
+	content := mustRead(t, filepath.Join(tmp, "api", "pet", "pet_api.go"))
+	if !strings.HasPrefix(content, "package pet ") || !strings.Contains(content, "models example.com/awesome/dto") {
+		t.Fatalf("expected package pet importing dto as models; got: %q", content)
+	}
+	if content := mustRead(t, filepath.Join(tmp, "api", "store", "store_api.go")); !strings.HasPrefix(content, "package store ") {
+		t.Fatalf("expected package store; got: %q", content)
+	}
+	if content := mustRead(t, filepath.Join(tmp, "api", "default", "default_api.go")); !strings.HasPrefix(content, "package pkgdefault ") {
+		t.Fatalf("expected the untagged operations in package pkgdefault; got: %q", content)
+	}
+	for _, tag := range []string{"pet", "store"} {
+		if content := mustRead(t, filepath.Join(tmp, "api", tag, "security.go")); content != "package "+tag {
+			t.Fatalf("expected security.go in package %s; got: %q", tag, content)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "api", "default", "security.go")); !os.IsNotExist(err) {
+		t.Fatalf("expected no security.go in the unsecured default package, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// manifestFile lists the files of the last generation in the output
+// directory, so that the files no longer generated can be removed.
+const manifestFile = ".gopenapi-manifest.json"
+
+type manifest struct {
+	// Files maps the generated files, relative to the output directory, to
+	// the sha256 of their content.
+	Files map[string]string `json:"files"`
+}
+
+// updateManifest removes the files of the previous generation that files
+// no longer include and writes the manifest of files. Stale files edited
+// since their generation are kept, and stay in the manifest, unless force
+// is set.
+func updateManifest(baseOut string, files []string, force bool) error {
+	manifestPath := filepath.Join(baseOut, manifestFile)
+	previous, err := readManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	current := manifest{Files: map[string]string{}}
+	for _, file := range files {
+		rel, err := filepath.Rel(baseOut, file)
+		if err != nil {
+			return err
+		}
+		sum, err := hashFile(file)
+		if err != nil {
+			return err
+		}
+		current.Files[filepath.ToSlash(rel)] = sum
+	}
+
+	stale := make([]string, 0, len(previous.Files))
+	for rel := range previous.Files {
+		stale = append(stale, rel)
+	}
+	sort.Strings(stale)
+	for _, rel := range stale {
+		sum := previous.Files[rel]
+		if _, ok := current.Files[rel]; ok || !isLocal(rel) {
+			continue
+		}
+		path := filepath.Join(baseOut, filepath.FromSlash(rel))
+		actual, err := hashFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if actual != sum && !force {
+			log.Printf("warning: keeping stale %s, it was edited since generation; use --force to remove it", path)
+			current.Files[rel] = sum
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		log.Printf("Removed %s", path)
+		removeEmptyDirs(filepath.Dir(path), baseOut)
+	}
+
+	data, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifestPath, append(data, '\n'), 0o644)
+}
+
+func readManifest(path string) (manifest, error) {
+	var m manifest
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// isLocal reports whether a manifest entry stays within the output
+// directory, so a tampered manifest cannot remove other files.
+func isLocal(rel string) bool {
+	return filepath.IsLocal(filepath.FromSlash(rel)) && !strings.Contains(rel, `\`)
+}
+
+// removeEmptyDirs removes dir and its parents up to, but excluding, root as
+// long as they are empty.
+func removeEmptyDirs(dir, root string) {
+	for {
+		rel, err := filepath.Rel(root, dir)
+		if err != nil || rel == "." || !filepath.IsLocal(rel) {
+			return
+		}
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateManifest_RemovesStaleFiles(t *testing.T) {
+	tmp := t.TempDir()
+	restore := chdir(t, tmp)
+	defer restore()
+
+	pet := filepath.Join("gen", "models", "pet_model.go")
+	store := filepath.Join("gen", "api", "store", "store_api.go")
+	mustWriteFile(t, pet, []byte("package models"))
+	mustWriteFile(t, store, []byte("package store"))
+	if err := updateManifest("gen", []string{pet, store}, false); err != nil {
+		t.Fatalf("updateManifest: %v", err)
+	}
+
+	// The store tag is gone from the spec.
+	if err := updateManifest("gen", []string{pet}, false); err != nil {
+		t.Fatalf("updateManifest: %v", err)
+	}
+	if _, err := os.Stat(store); !os.IsNotExist(err) {
+		t.Fatalf("expected stale %s to be removed, got %v", store, err)
+	}
+	if _, err := os.Stat(filepath.Dir(store)); !os.IsNotExist(err) {
+		t.Fatalf("expected empty package directory to be removed, got %v", err)
+	}
+	if _, err := os.Stat(pet); err != nil {
+		t.Fatalf("expected %s to be kept: %v", pet, err)
+	}
+	manifest := mustRead(t, filepath.Join("gen", manifestFile))
+	if !strings.Contains(manifest, `"models/pet_model.go"`) || strings.Contains(manifest, "store_api.go") {
+		t.Fatalf("unexpected manifest: %s", manifest)
+	}
+}
+
+func TestUpdateManifest_KeepsEditedFilesUnlessForced(t *testing.T) {
+	tmp := t.TempDir()
+	restore := chdir(t, tmp)
+	defer restore()
+
+	pet := filepath.Join("models", "pet_model.go")
+	mustWriteFile(t, pet, []byte("package models"))
+	if err := updateManifest(".", []string{pet}, false); err != nil {
+		t.Fatalf("updateManifest: %v", err)
+	}
+	mustWriteFile(t, pet, []byte("package models\n\n// edited by hand"))
+
+	if err := updateManifest(".", nil, false); err != nil {
+		t.Fatalf("updateManifest: %v", err)
+	}
+	if _, err := os.Stat(pet); err != nil {
+		t.Fatalf("expected edited %s to be kept: %v", pet, err)
+	}
+	if manifest := mustRead(t, manifestFile); !strings.Contains(manifest, "models/pet_model.go") {
+		t.Fatalf("expected edited file to stay in the manifest: %s", manifest)
+	}
+
+	if err := updateManifest(".", nil, true); err != nil {
+		t.Fatalf("updateManifest: %v", err)
+	}
+	if _, err := os.Stat(pet); !os.IsNotExist(err) {
+		t.Fatalf("expected edited %s to be removed with force, got %v", pet, err)
+	}
+}
+
+func TestUpdateManifest_IgnoresEntriesOutsideOutput(t *testing.T) {
+	tmp := t.TempDir()
+	restore := chdir(t, tmp)
+	defer restore()
+
+	outside := filepath.Join(tmp, "main.go")
+	mustWriteFile(t, outside, []byte("package main"))
+	mustWriteFile(t, filepath.Join("gen", manifestFile), []byte(`{"files": {"../main.go": "x"}}`))
+
+	if err := updateManifest("gen", nil, true); err != nil {
+		t.Fatalf("updateManifest: %v", err)
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Fatalf("expected %s outside of the output to be kept: %v", outside, err)
+	}
+}
@@ -0,0 +1,84 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// sink receives the files produced by the generator.
+type sink interface {
+	WriteFile(path string, data []byte) error
+}
+
+// diskSink writes the generated files to disk. Files whose content did not
+// change are left untouched, so tools watching the output only see the
+// files that did.
+type diskSink struct{}
+
+func (diskSink) WriteFile(path string, data []byte) error {
+	if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, data) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	log.Printf("Generated %s", path)
+	return nil
+}
+
+// memorySink keeps the generated files in memory, in the order they were
+// produced.
+type memorySink struct {
+	paths []string
+	files map[string][]byte
+}
+
+func newMemorySink() *memorySink {
+	return &memorySink{files: map[string][]byte{}}
+}
+
+func (s *memorySink) WriteFile(path string, data []byte) error {
+	if _, ok := s.files[path]; !ok {
+		s.paths = append(s.paths, path)
+	}
+	s.files[path] = data
+	return nil
+}
+
+// dryRunSink lists the generated files and their sizes instead of writing
+// them.
+type dryRunSink struct {
+	w io.Writer
+}
+
+func (s dryRunSink) WriteFile(path string, data []byte) error {
+	_, err := fmt.Fprintf(s.w, "%8d %s\n", len(data), path)
+	return err
+}
+
+// streamSink writes the generated files one after the other, each preceded
+// by a separator naming it.
+type streamSink struct {
+	w     io.Writer
+	count int
+}
+
+func (s *streamSink) WriteFile(path string, data []byte) error {
+	separator := "==> %s <==\n"
+	if s.count > 0 {
+		separator = "\n" + separator
+	}
+	s.count++
+	if _, err := fmt.Fprintf(s.w, separator, path); err != nil {
+		return err
+	}
+	_, err := s.w.Write(data)
+	return err
+}
@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDryRunSink(t *testing.T) {
+	var out strings.Builder
+	dst := dryRunSink{&out}
+	if err := dst.WriteFile(filepath.Join("gen", "models", "pet_model.go"), []byte("package models\n")); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if want := "      15 " + filepath.Join("gen", "models", "pet_model.go") + "\n"; out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+}
+
+func TestStreamSink(t *testing.T) {
+	var out strings.Builder
+	dst := &streamSink{w: &out}
+	for _, file := range []struct{ path, data string }{
+		{"pet_model.go", "package models\n"},
+		{"pet_api.go", "package api\n"},
+	} {
+		if err := dst.WriteFile(file.path, []byte(file.data)); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	want := "==> pet_model.go <==\npackage models\n\n==> pet_api.go <==\npackage api\n"
+	if out.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, out.String())
+	}
+}
+
+func TestMemorySink(t *testing.T) {
+	dst := newMemorySink()
+	for _, file := range []struct{ path, data string }{
+		{"pet_model.go", "package models\n"},
+		{"pet_api.go", "package api\n"},
+		{"pet_model.go", "package models\n\n// rewritten\n"},
+	} {
+		if err := dst.WriteFile(file.path, []byte(file.data)); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	if got := strings.Join(dst.paths, " "); got != "pet_model.go pet_api.go" {
+		t.Errorf("expected each path once in the order first written, got %q", got)
+	}
+	if got := string(dst.files["pet_model.go"]); got != "package models\n\n// rewritten\n" {
+		t.Errorf("expected the last content of pet_model.go, got %q", got)
+	}
+}
+
+func TestDiskSink_SkipsUnchangedFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models", "pet_model.go")
+	if err := (diskSink{}).WriteFile(path, []byte("package models\n")); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+
+	if err := (diskSink{}).WriteFile(path, []byte("package models\n")); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || !info.ModTime().Equal(old) {
+		t.Errorf("expected unchanged %s not to be rewritten", path)
+	}
+	if err := (diskSink{}).WriteFile(path, []byte("package models\n\n// changed\n")); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if got := mustRead(t, path); got != "package models\n\n// changed\n" {
+		t.Errorf("expected changed %s to be rewritten, got %q", path, got)
+	}
+}
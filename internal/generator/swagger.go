@@ -0,0 +1,191 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/oasdiff/yaml"
+	"gopenapi/internal/mapper"
+	"gopenapi/internal/overlay"
+)
+
+// LoadSpec loads the OpenAPI document at path, with the overlays applied
+// and the references to other files internalized. Swagger 2.0 documents are
+// converted to OpenAPI 3. The returned warnings describe the overlay actions
+// that changed nothing and what the conversion could not carry over.
+func LoadSpec(path string, overlays ...string) (*openapi3.T, []string, error) {
+	data, warnings, err := readSpec(path, overlays)
+	if err != nil {
+		return nil, nil, err
+	}
+	var version struct {
+		Swagger string `json:"swagger"`
+	}
+	if err := yaml.Unmarshal(data, &version); err != nil {
+		return nil, nil, err
+	}
+	if version.Swagger == "" {
+		if data, err = mapper.NormalizeExclusiveBounds(data); err != nil {
+			return nil, nil, err
+		}
+		doc, err := newLoader().LoadFromDataWithPath(data, specURL(path))
+		if err != nil {
+			return nil, nil, err
+		}
+		// Schemas of other files become components, generated as models.
+		doc.InternalizeRefs(context.Background(), nil)
+		return doc, warnings, nil
+	}
+	if version.Swagger != "2.0" {
+		return nil, nil, fmt.Errorf("unsupported swagger version %q", version.Swagger)
+	}
+
+	var doc2 openapi2.T
+	if err := yaml.Unmarshal(data, &doc2); err != nil {
+		return nil, nil, err
+	}
+	doc, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert swagger 2.0 document: %w", err)
+	}
+	conversion := append(convertCollectionFormats(&doc2, doc), droppedExamples(&doc2)...)
+	sort.Strings(conversion)
+	warnings = append(warnings, "converted swagger 2.0 document to OpenAPI 3")
+	return doc, append(warnings, conversion...), nil
+}
+
+// readSpec reads the document at path and applies the overlays to it.
+func readSpec(path string, overlays []string) ([]byte, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || len(overlays) == 0 {
+		return data, nil, err
+	}
+	return overlay.ApplyFiles(data, overlays)
+}
+
+// readFromURI reads referenced files like the default reader of the loader
+// but without its process-wide cache, which would hide their changes from
+// watch mode, and normalizes their exclusive bounds like LoadSpec does.
+func readFromURI(loader *openapi3.Loader, location *url.URL) ([]byte, error) {
+	data, err := openapi3.ReadFromURIs(openapi3.ReadFromHTTP(http.DefaultClient), openapi3.ReadFromFile)(loader, location)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.NormalizeExclusiveBounds(data)
+}
+
+// newLoader returns a loader of OpenAPI 3 documents that resolves references
+// to other files.
+func newLoader() *openapi3.Loader {
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = readFromURI
+	return loader
+}
+
+// specURL returns the location of the spec at path, which its relative
+// references are resolved against.
+func specURL(path string) *url.URL {
+	return &url.URL{Path: filepath.ToSlash(path)}
+}
+
+// collectionStyles maps the collectionFormat of query parameters to their
+// OpenAPI 3 style and explode.
+var collectionStyles = map[string]struct {
+	style   string
+	explode bool
+}{
+	"csv":   {openapi3.SerializationForm, false},
+	"ssv":   {openapi3.SerializationSpaceDelimited, false},
+	"pipes": {openapi3.SerializationPipeDelimited, false},
+	"multi": {openapi3.SerializationForm, true},
+}
+
+// convertCollectionFormats sets the style of array parameters from their
+// collectionFormat, which openapi2conv drops, and reports the formats
+// OpenAPI 3 cannot express.
+func convertCollectionFormats(doc2 *openapi2.T, doc *openapi3.T) []string {
+	var warnings []string
+	convert := func(at string, param2 *openapi2.Parameter, param *openapi3.Parameter) {
+		format := param2.CollectionFormat
+		if format == "" || format == "csv" && param2.In != "query" && param2.In != "formData" {
+			// csv is the default and the simple style of path and header parameters.
+			return
+		}
+		if style, ok := collectionStyles[format]; ok && param2.In == "query" && param != nil {
+			explode := style.explode
+			param.Style, param.Explode = style.style, &explode
+			return
+		}
+		warnings = append(warnings, fmt.Sprintf("%s: collectionFormat %s of %s parameter is lost in conversion", at, format, param2.In))
+	}
+
+	for name, param2 := range doc2.Parameters {
+		var param *openapi3.Parameter
+		if ref := doc.Components.Parameters[name]; ref != nil {
+			param = ref.Value
+		}
+		convert("#/parameters/"+name, param2, param)
+	}
+	for path, item2 := range doc2.Paths {
+		item := doc.Paths.Value(path)
+		if item == nil {
+			continue
+		}
+		for _, param2 := range item2.Parameters {
+			if param2.Ref == "" {
+				convert(fmt.Sprintf("%s parameter %s", path, param2.Name), param2, findParameter(item.Parameters, param2))
+			}
+		}
+		for method, operation2 := range item2.Operations() {
+			operation := item.GetOperation(method)
+			if operation == nil {
+				continue
+			}
+			for _, param2 := range operation2.Parameters {
+				if param2.Ref == "" {
+					convert(fmt.Sprintf("%s %s parameter %s", method, path, param2.Name), param2, findParameter(operation.Parameters, param2))
+				}
+			}
+		}
+	}
+	return warnings
+}
+
+func findParameter(params openapi3.Parameters, param2 *openapi2.Parameter) *openapi3.Parameter {
+	for _, ref := range params {
+		if ref.Value != nil && ref.Value.Name == param2.Name && ref.Value.In == param2.In {
+			return ref.Value
+		}
+	}
+	return nil
+}
+
+// droppedExamples reports the response examples openapi2conv leaves out.
+func droppedExamples(doc2 *openapi2.T) []string {
+	var warnings []string
+	report := func(at string, resp *openapi2.Response) {
+		if resp != nil && len(resp.Examples) > 0 {
+			warnings = append(warnings, at+": response examples are lost in conversion")
+		}
+	}
+	for name, resp := range doc2.Responses {
+		report("#/responses/"+name, resp)
+	}
+	for path, item := range doc2.Paths {
+		for method, operation := range item.Operations() {
+			for status, resp := range operation.Responses {
+				report(fmt.Sprintf("%s %s response %s", method, path, status), resp)
+			}
+		}
+	}
+	return warnings
+}
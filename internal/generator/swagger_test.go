@@ -0,0 +1,151 @@
+package generator
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadSpec_Swagger2(t *testing.T) {
+	tmp := t.TempDir()
+	specPath := filepath.Join(tmp, "swagger.yaml")
+	mustWriteFile(t, specPath, []byte(`
+swagger: "2.0"
+info: {title: legacy, version: "1"}
+parameters:
+  ids: {name: ids, in: query, type: array, items: {type: integer}, collectionFormat: tsv}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+        - {name: tags, in: query, type: array, items: {type: string}, collectionFormat: multi}
+        - {name: kinds, in: query, type: array, items: {type: string}, collectionFormat: pipes}
+        - {name: X-Flags, in: header, type: array, items: {type: string}, collectionFormat: ssv}
+        - {$ref: '#/parameters/ids'}
+      responses:
+        200:
+          description: ok
+          schema: {type: array, items: {$ref: '#/definitions/Pet'}}
+          examples: {application/json: [{name: rex}]}
+definitions:
+  Pet:
+    type: object
+    properties:
+      name: {type: string}
+`))
+
+	doc, warnings, err := LoadSpec(specPath)
+	if err != nil {
+		t.Fatalf("LoadSpec: %v", err)
+	}
+	want := []string{
+		"converted swagger 2.0 document to OpenAPI 3",
+		"#/parameters/ids: collectionFormat tsv of query parameter is lost in conversion",
+		"GET /pets parameter X-Flags: collectionFormat ssv of header parameter is lost in conversion",
+		"GET /pets response 200: response examples are lost in conversion",
+	}
+	if !slices.Equal(warnings, want) {
+		t.Errorf("expected warnings\n%q\ngot\n%q", want, warnings)
+	}
+	if doc.Components.Schemas["Pet"] == nil {
+		t.Errorf("expected definitions to become component schemas")
+	}
+
+	params := doc.Paths.Value("/pets").Get.Parameters
+	tags, kinds := params.GetByInAndName("query", "tags"), params.GetByInAndName("query", "kinds")
+	if tags.Style != "form" || tags.Explode == nil || !*tags.Explode {
+		t.Errorf("expected multi to become an exploded form, got %q %v", tags.Style, tags.Explode)
+	}
+	if kinds.Style != "pipeDelimited" || kinds.Explode == nil || *kinds.Explode {
+		t.Errorf("expected pipes to become pipeDelimited, got %q %v", kinds.Style, kinds.Explode)
+	}
+}
+
+func TestLoadSpec_OpenAPI3(t *testing.T) {
+	tmp := t.TempDir()
+	specPath := filepath.Join(tmp, "openapi.yaml")
+	mustWriteFile(t, specPath, []byte(`
+openapi: 3.0.3
+info: {title: t, version: "1"}
+paths: {}
+`))
+	doc, warnings, err := LoadSpec(specPath)
+	if err != nil {
+		t.Fatalf("LoadSpec: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" || len(warnings) != 0 {
+		t.Errorf("expected the document as is without warnings, got %s %q", doc.OpenAPI, warnings)
+	}
+}
+
+func TestLoadSpec_ExternalRefs(t *testing.T) {
+	tmp := t.TempDir()
+	specPath := filepath.Join(tmp, "api.yaml")
+	mustWriteFile(t, specPath, []byte(externalSpec))
+	mustWriteFile(t, filepath.Join(tmp, "schemas.yaml"), []byte("Thing: {type: object, properties: {name: {type: string}}}\n"))
+
+	doc, _, err := LoadSpec(specPath)
+	if err != nil {
+		t.Fatalf("LoadSpec: %v", err)
+	}
+	if doc.Components == nil || len(doc.Components.Schemas) != 1 {
+		t.Fatalf("expected the referenced schema to become a component, got %+v", doc.Components)
+	}
+}
+
+func TestLoadSpec_NumericExclusiveBounds(t *testing.T) {
+	tmp := t.TempDir()
+	specPath := filepath.Join(tmp, "api.yaml")
+	mustWriteFile(t, specPath, []byte(strings.Replace(externalSpec, "3.0.3", "3.1.0", 1)))
+	mustWriteFile(t, filepath.Join(tmp, "schemas.yaml"), []byte(
+		"Thing: {type: object, properties: {age: {type: integer, exclusiveMinimum: 0, maximum: 10, exclusiveMaximum: 20}}}\n"))
+
+	doc, _, err := LoadSpec(specPath)
+	if err != nil {
+		t.Fatalf("LoadSpec: %v", err)
+	}
+	thing := doc.Components.Schemas["schemas_Thing"]
+	if thing == nil {
+		t.Fatalf("expected the referenced schema to become a component, got %+v", doc.Components.Schemas)
+	}
+	age := thing.Value.Properties["age"].Value
+	if age.Min == nil || *age.Min != 0 || !age.ExclusiveMin {
+		t.Errorf("expected an exclusive minimum of 0, got %v %t", age.Min, age.ExclusiveMin)
+	}
+	if age.Max == nil || *age.Max != 10 || age.ExclusiveMax {
+		t.Errorf("expected the tighter inclusive maximum of 10, got %v %t", age.Max, age.ExclusiveMax)
+	}
+}
+
+func TestLoadSpec_Overlays(t *testing.T) {
+	tmp := t.TempDir()
+	specPath := filepath.Join(tmp, "api.yaml")
+	overlayPath := filepath.Join(tmp, "overlay.yaml")
+	mustWriteFile(t, specPath, []byte(externalSpec))
+	mustWriteFile(t, filepath.Join(tmp, "schemas.yaml"), []byte("Thing: {type: object, properties: {name: {type: string}}}\n"))
+	mustWriteFile(t, overlayPath, []byte(`
+overlay: 1.0.0
+info: {title: fixes, version: "1"}
+actions:
+  - target: $.paths['/things'].get
+    update: {operationId: listAllThings}
+  - target: $.paths['/missing']
+    remove: true
+`))
+
+	doc, warnings, err := LoadSpec(specPath, overlayPath)
+	if err != nil {
+		t.Fatalf("LoadSpec: %v", err)
+	}
+	if got := doc.Paths.Find("/things").Get.OperationID; got != "listAllThings" {
+		t.Errorf("expected the overlay to rename the operation, got %s", got)
+	}
+	if len(doc.Components.Schemas) != 1 {
+		t.Errorf("expected the referenced schema to be loaded, got %v", doc.Components.Schemas)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "action 2") {
+		t.Errorf("expected a warning for the second action, got %v", warnings)
+	}
+}
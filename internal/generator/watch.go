@@ -0,0 +1,154 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/oasdiff/yaml"
+	"gopenapi/internal/config"
+)
+
+// pollInterval is how often the watched files are checked for changes, and
+// debounce how long they must then stay unchanged before regenerating, so
+// an editor saving several files at once triggers a single run.
+var (
+	pollInterval = 250 * time.Millisecond
+	debounce     = 500 * time.Millisecond
+)
+
+// Watch generates the code, then generates it again whenever the config
+// file, the spec, a file the spec references or a template changes, until
+// ctx is done. The config is read again on every run. Failures are logged
+// and the generated files of the last successful run are left in place.
+func Watch(ctx context.Context, configPath string, force bool) {
+	inputs := watchRun(configPath, force, []string{configPath, templateDir})
+	last := snapshot(inputs)
+	var changedAt time.Time
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			current := snapshot(inputs)
+			if !maps.Equal(current, last) {
+				last, changedAt = current, now
+				continue
+			}
+			if changedAt.IsZero() || now.Sub(changedAt) < debounce {
+				continue
+			}
+			changedAt = time.Time{}
+			log.Printf("Change detected, regenerating")
+			inputs = watchRun(configPath, force, inputs)
+			last = snapshot(inputs)
+		}
+	}
+}
+
+// watchRun generates the code once and returns the files to watch. When
+// the config cannot be read, the previous inputs are watched until it is
+// fixed.
+func watchRun(configPath string, force bool, previous []string) []string {
+	cfg, err := config.ParseConfig(configPath)
+	if err != nil {
+		log.Printf("error: failed to parse config: %v", err)
+		return previous
+	}
+	inputs := []string{configPath, templateDir}
+	var errs []error
+	for _, target := range cfg.Generations() {
+		specs, err := inspectSpec(target.Input, target.Overlays)
+		inputs = append(inputs, target.Overlays...)
+		inputs = append(inputs, specs...)
+		if err != nil && len(cfg.Targets) > 0 {
+			err = fmt.Errorf("target %s: %w", target.Name, err)
+		}
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		log.Printf("error: %v", err)
+		return inputs
+	}
+
+	gen := NewGenerator(cfg)
+	gen.Force = force
+	if err := gen.Run(); err != nil {
+		log.Printf("error: %v", err)
+		return inputs
+	}
+	log.Printf("Watching for changes")
+	return inputs
+}
+
+// inspectSpec returns the spec at path and the local files it references,
+// even when loading fails part way, and reports the validation errors of
+// the spec patched by the overlays. Swagger 2.0 documents are not validated
+// and their references are not followed.
+func inspectSpec(path string, overlays []string) ([]string, error) {
+	files := []string{path}
+	data, _, err := readSpec(path, overlays)
+	if err != nil {
+		return files, err
+	}
+	var version struct {
+		Swagger string `json:"swagger"`
+	}
+	if err := yaml.Unmarshal(data, &version); err != nil || version.Swagger != "" {
+		return files, nil
+	}
+
+	loader := newLoader()
+	loader.ReadFromURIFunc = func(loader *openapi3.Loader, location *url.URL) ([]byte, error) {
+		if location.Scheme == "" || location.Scheme == "file" {
+			files = append(files, filepath.FromSlash(location.Path))
+		}
+		return readFromURI(loader, location)
+	}
+	doc, err := loader.LoadFromDataWithPath(data, specURL(path))
+	if err != nil {
+		return files, fmt.Errorf("failed to load OpenAPI spec: %w", err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return files, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+	return files, nil
+}
+
+// fileState identifies a version of a watched file.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshot returns the state of the existing files among paths, walking
+// directories so added and removed files are noticed too.
+func snapshot(paths []string) map[string]fileState {
+	states := map[string]fileState{}
+	for _, path := range paths {
+		// WalkDir does not follow a symlinked root, such as a linked
+		// template directory.
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+		filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return nil
+			}
+			if info, err := entry.Info(); err == nil {
+				states[file] = fileState{info.ModTime(), info.Size()}
+			}
+			return nil
+		})
+	}
+	return states
+}
@@ -0,0 +1,67 @@
+package generator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const externalSpec = `
+openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /things:
+    get:
+      operationId: listThings
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: 'schemas.yaml#/Thing'}
+`
+
+func TestInspectSpec_ReferencedFiles(t *testing.T) {
+	tmp := t.TempDir()
+	specPath := filepath.Join(tmp, "api.yaml")
+	schemasPath := filepath.Join(tmp, "schemas.yaml")
+	mustWriteFile(t, specPath, []byte(externalSpec))
+	mustWriteFile(t, schemasPath, []byte("Thing: {type: object, properties: {name: {type: string}}}\n"))
+
+	files, err := inspectSpec(specPath, nil)
+	if err != nil {
+		t.Fatalf("inspectSpec: %v", err)
+	}
+	states := snapshot(files)
+	for _, path := range []string{specPath, schemasPath} {
+		if _, ok := states[path]; !ok {
+			t.Errorf("expected %s to be watched, got %v", path, files)
+		}
+	}
+
+	mustWriteFile(t, schemasPath, []byte("Thing: {type: object, properties: {name: {type: strin}}}\n"))
+	files, err = inspectSpec(specPath, nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid OpenAPI spec") {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+	if _, ok := snapshot(files)[schemasPath]; !ok {
+		t.Errorf("expected %s to stay watched, got %v", schemasPath, files)
+	}
+}
+
+func TestSnapshot_DetectsChanges(t *testing.T) {
+	tmp := t.TempDir()
+	tmpl := filepath.Join(tmp, "templates", "model.tmpl")
+	mustWriteFile(t, tmpl, []byte("package {{.Package}}\n"))
+
+	before := snapshot([]string{filepath.Join(tmp, "templates"), filepath.Join(tmp, "missing.yaml")})
+	if len(before) != 1 {
+		t.Fatalf("expected one file in the snapshot, got %v", before)
+	}
+	mustWriteFile(t, tmpl, []byte("package {{.Package}}\n\n// edited\n"))
+	mustWriteFile(t, filepath.Join(tmp, "templates", "api.tmpl"), []byte("package api\n"))
+	after := snapshot([]string{filepath.Join(tmp, "templates")})
+	if len(after) != 2 || after[tmpl] == before[tmpl] {
+		t.Errorf("expected the edit and the new template to be noticed, got %v then %v", before, after)
+	}
+}
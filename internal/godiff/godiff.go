@@ -0,0 +1,263 @@
+// Package godiff compares the exported Go API of two sets of generated
+// files, to show reviewers the Go-level impact of a spec change.
+package godiff
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"gopenapi/internal/specdiff"
+)
+
+// symbol is an exported declaration of the generated code.
+type symbol struct {
+	kind string // type, field, method, func, const or var
+	// signature is the type of the declaration, parameter names left out.
+	signature string
+	// tag is the struct tag of a field.
+	tag string
+	// inInterface marks the methods of an interface, adding one breaks its
+	// implementations.
+	inInterface bool
+}
+
+func (s symbol) String() string {
+	if s.signature == "" {
+		return s.kind
+	}
+	return s.kind + " " + s.signature
+}
+
+// Compare returns the changes to the exported types, fields, methods and
+// functions from the base files to the revision files, both keyed by path.
+// Locations name symbols after their package, e.g. models.Pet.Name.
+// Removing or changing a symbol is breaking, adding one is not, except for
+// interface methods. Struct tag changes are reported as not breaking.
+func Compare(base, revision map[string][]byte) ([]specdiff.Change, error) {
+	baseSymbols, err := symbols(base)
+	if err != nil {
+		return nil, err
+	}
+	revisionSymbols, err := symbols(revision)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for name := range baseSymbols {
+		names[name] = true
+	}
+	for name := range revisionSymbols {
+		names[name] = true
+	}
+	var changes []specdiff.Change
+	report := func(at string, breaking bool, format string, args ...any) {
+		changes = append(changes, specdiff.Change{Location: at, Message: fmt.Sprintf(format, args...), Breaking: breaking})
+	}
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		old, inBase := baseSymbols[name]
+		sym, inRevision := revisionSymbols[name]
+		// The members of a type added or removed as a whole are not listed.
+		if parent := name[:strings.LastIndex(name, ".")]; strings.Contains(parent, ".") {
+			_, parentInBase := baseSymbols[parent]
+			_, parentInRevision := revisionSymbols[parent]
+			if !inBase && !parentInBase || !inRevision && !parentInRevision {
+				continue
+			}
+		}
+		switch {
+		case !inRevision:
+			report(name, true, "%s removed", old)
+		case !inBase:
+			report(name, sym.inInterface, "%s added", sym)
+		case old.kind != sym.kind:
+			report(name, true, "%s became %s", old, sym)
+		default:
+			if old.signature != sym.signature {
+				report(name, true, "%s changed from %s to %s", sym.kind, old.signature, sym.signature)
+			}
+			if old.tag != sym.tag {
+				report(name, false, "tag changed from %s to %s", old.tag, sym.tag)
+			}
+		}
+	}
+	return changes, nil
+}
+
+// symbols parses the Go files and returns their exported symbols by
+// qualified name.
+func symbols(files map[string][]byte) (map[string]symbol, error) {
+	fset := token.NewFileSet()
+	symbols := map[string]symbol{}
+	for filePath, data := range files {
+		if path.Ext(filePath) != ".go" {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filePath, data, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse generated file %s: %w", filePath, err)
+		}
+		c := collector{fset: fset, pkg: file.Name.Name, symbols: symbols}
+		for _, decl := range file.Decls {
+			c.decl(decl)
+		}
+	}
+	return symbols, nil
+}
+
+type collector struct {
+	fset    *token.FileSet
+	pkg     string
+	symbols map[string]symbol
+}
+
+func (c *collector) add(name string, sym symbol) {
+	c.symbols[c.pkg+"."+name] = sym
+}
+
+func (c *collector) decl(decl ast.Decl) {
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		if !decl.Name.IsExported() {
+			return
+		}
+		if decl.Recv == nil {
+			c.add(decl.Name.Name, symbol{kind: "func", signature: c.funcSignature(decl.Type)})
+			return
+		}
+		recv := receiverName(decl.Recv.List[0].Type)
+		if ast.IsExported(recv) {
+			c.add(recv+"."+decl.Name.Name, symbol{kind: "method", signature: c.funcSignature(decl.Type)})
+		}
+	case *ast.GenDecl:
+		for _, spec := range decl.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				if spec.Name.IsExported() {
+					c.typeSpec(spec)
+				}
+			case *ast.ValueSpec:
+				for _, name := range spec.Names {
+					if name.IsExported() {
+						c.add(name.Name, symbol{kind: decl.Tok.String(), signature: c.expr(spec.Type)})
+					}
+				}
+			}
+		}
+	}
+}
+
+func (c *collector) typeSpec(spec *ast.TypeSpec) {
+	name := spec.Name.Name
+	switch typ := spec.Type.(type) {
+	case *ast.StructType:
+		c.add(name, symbol{kind: "type", signature: "struct"})
+		for _, field := range typ.Fields.List {
+			var tag string
+			if field.Tag != nil {
+				tag = field.Tag.Value
+			}
+			for _, fieldName := range fieldNames(field) {
+				if ast.IsExported(fieldName) {
+					c.add(name+"."+fieldName, symbol{kind: "field", signature: c.expr(field.Type), tag: tag})
+				}
+			}
+		}
+	case *ast.InterfaceType:
+		c.add(name, symbol{kind: "type", signature: "interface"})
+		for _, method := range typ.Methods.List {
+			ft, ok := method.Type.(*ast.FuncType)
+			if !ok {
+				continue
+			}
+			for _, methodName := range method.Names {
+				if methodName.IsExported() {
+					c.add(name+"."+methodName.Name, symbol{kind: "method", signature: c.funcSignature(ft), inInterface: true})
+				}
+			}
+		}
+	default:
+		signature := c.expr(spec.Type)
+		if spec.Assign.IsValid() {
+			signature = "= " + signature
+		}
+		c.add(name, symbol{kind: "type", signature: signature})
+	}
+}
+
+// fieldNames returns the names of a struct field, the type name of an
+// embedded one.
+func fieldNames(field *ast.Field) []string {
+	if len(field.Names) == 0 {
+		return []string{receiverName(field.Type)}
+	}
+	names := make([]string, len(field.Names))
+	for i, name := range field.Names {
+		names[i] = name.Name
+	}
+	return names
+}
+
+// receiverName returns the name of the type of a receiver or embedded
+// field, without pointer, package or type parameters.
+func receiverName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(expr.X)
+	case *ast.SelectorExpr:
+		return expr.Sel.Name
+	case *ast.IndexExpr:
+		return receiverName(expr.X)
+	case *ast.IndexListExpr:
+		return receiverName(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	}
+	return ""
+}
+
+// funcSignature prints a function type without its parameter names, which
+// callers do not depend on.
+func (c *collector) funcSignature(ft *ast.FuncType) string {
+	types := func(fields *ast.FieldList) []string {
+		var list []string
+		if fields == nil {
+			return list
+		}
+		for _, field := range fields.List {
+			n := max(len(field.Names), 1)
+			for range n {
+				list = append(list, c.expr(field.Type))
+			}
+		}
+		return list
+	}
+	signature := "func(" + strings.Join(types(ft.Params), ", ") + ")"
+	switch results := types(ft.Results); len(results) {
+	case 0:
+	case 1:
+		signature += " " + results[0]
+	default:
+		signature += " (" + strings.Join(results, ", ") + ")"
+	}
+	return signature
+}
+
+func (c *collector) expr(expr ast.Expr) string {
+	if expr == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, c.fset, expr); err != nil {
+		return fmt.Sprintf("%T", expr)
+	}
+	return buf.String()
+}
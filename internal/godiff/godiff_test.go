@@ -0,0 +1,86 @@
+package godiff
+
+import (
+	"slices"
+	"testing"
+
+	"gopenapi/internal/specdiff"
+)
+
+func TestCompare(t *testing.T) {
+	base := map[string][]byte{
+		"gen/models/pet_model.go": []byte(`package models
+
+type Pet struct {
+	Name string ` + "`json:\"name\"`" + `
+	Age int
+	internal bool
+}
+
+func (m Pet) Validate() error { return nil }
+`),
+		"gen/api/pet_api.go": []byte(`package api
+
+type PetAPI struct{}
+
+func (api *PetAPI) GetPet(c *gin.Context) {}
+
+func (api *PetAPI) DeletePet(c *gin.Context) {}
+
+type GetPet404Response struct {
+	Body string
+}
+
+type Authenticator interface {
+	Authenticate(c *gin.Context, scheme string) error
+}
+`),
+		"gen/api/openapi.json": []byte(`{}`),
+	}
+	revision := map[string][]byte{
+		"gen/models/pet_model.go": []byte(`package models
+
+type Pet struct {
+	Name string ` + "`json:\"petName\"`" + `
+	Age float64
+	Tag *string
+}
+
+func (pet Pet) Validate() error { return nil }
+`),
+		"gen/api/pet_api.go": []byte(`package api
+
+type PetAPI struct{}
+
+func (api *PetAPI) GetPet(ctx *gin.Context) {}
+
+type Authenticator interface {
+	Authenticate(c *gin.Context, scheme string) error
+	Scopes() []string
+}
+`),
+	}
+
+	got, err := Compare(base, revision)
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	want := []specdiff.Change{
+		{Location: "api.Authenticator.Scopes", Message: "method func() []string added", Breaking: true},
+		{Location: "api.GetPet404Response", Message: "type struct removed", Breaking: true},
+		{Location: "api.PetAPI.DeletePet", Message: "method func(*gin.Context) removed", Breaking: true},
+		{Location: "models.Pet.Age", Message: "field changed from int to float64", Breaking: true},
+		{Location: "models.Pet.Name", Message: "tag changed from `json:\"name\"` to `json:\"petName\"`"},
+		{Location: "models.Pet.Tag", Message: "field *string added"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected changes\n%+v\ngot\n%+v", want, got)
+	}
+}
+
+func TestCompare_InvalidGo(t *testing.T) {
+	files := map[string][]byte{"gen/models/pet_model.go": []byte("package models\n\ntype Pet struct {")}
+	if _, err := Compare(files, files); err == nil {
+		t.Errorf("expected an error for unparsable generated code")
+	}
+}
@@ -0,0 +1,89 @@
+package mapper
+
+import (
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopenapi/internal/templates"
+	"strconv"
+	"strings"
+)
+
+// defaultLiteral renders the default value of a schema as a Go literal of
+// goType. Defaults of other types, e.g. objects, are not supported and
+// yield an empty string.
+func defaultLiteral(schema *openapi3.Schema, goType string) string {
+	if schema == nil || schema.Default == nil {
+		return ""
+	}
+	if strings.HasPrefix(goType, "[]") {
+		items, ok := schema.Default.([]any)
+		if !ok {
+			return ""
+		}
+		itemType := strings.TrimPrefix(goType, "[]")
+		values := make([]string, len(items))
+		for i, item := range items {
+			values[i] = scalarLiteral(item, itemType)
+			if values[i] == "" {
+				return ""
+			}
+		}
+		return goType + "{" + strings.Join(values, ", ") + "}"
+	}
+	return scalarLiteral(schema.Default, goType)
+}
+
+func scalarLiteral(v any, goType string) string {
+	switch goType {
+	case "string":
+		if s, ok := v.(string); ok {
+			return strconv.Quote(s)
+		}
+	case "int":
+		if n, ok := v.(float64); ok && n == float64(int64(n)) {
+			return strconv.FormatInt(int64(n), 10)
+		}
+	case "float64":
+		if n, ok := v.(float64); ok {
+			return strconv.FormatFloat(n, 'g', -1, 64)
+		}
+	case "bool":
+		if b, ok := v.(bool); ok {
+			return strconv.FormatBool(b)
+		}
+	}
+	return ""
+}
+
+// formDefault renders a default for gin's form, header and uri binding,
+// which separates array values with semicolons.
+func formDefault(v any) string {
+	var value string
+	if items, ok := v.([]any); ok {
+		values := make([]string, len(items))
+		for i, item := range items {
+			values[i] = fmt.Sprint(item)
+		}
+		value = strings.Join(values, ";")
+	} else if v != nil {
+		value = fmt.Sprint(v)
+	}
+	if strings.Contains(value, ",") {
+		return ""
+	}
+	return value
+}
+
+// ApplyDefaultsOnUnmarshal marks the models with defaults to get an
+// UnmarshalJSON method filling absent fields with them.
+func ApplyDefaultsOnUnmarshal(models []templates.Model) {
+	for i := range models {
+		for _, field := range models[i].Fields {
+			if field.Default != "" {
+				models[i].UnmarshalDefaults = true
+				models[i].Imports = uniqueSorted(append(models[i].Imports, "encoding/json"))
+				break
+			}
+		}
+	}
+}
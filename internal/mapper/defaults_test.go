@@ -0,0 +1,95 @@
+package mapper
+
+import (
+	"slices"
+	"testing"
+
+	"gopenapi/internal/templates"
+)
+
+func TestMapModelsFromSchemas_Defaults(t *testing.T) {
+	doc := loadDoc(t, `
+openapi: 3.0.0
+info: {title: t, version: "1"}
+paths: {}
+components:
+  schemas:
+    Item:
+      type: object
+      properties:
+        name: {type: string, default: "un\"named"}
+        count: {type: integer, default: 3}
+        ratio: {type: number, default: 0.5}
+        active: {type: boolean, default: false}
+        labels: {type: array, items: {type: string}, default: [x, y]}
+        meta: {type: object, default: {a: 1}}
+        plain: {type: string}
+`)
+	models := MapModelsFromSchemas(doc)
+	item := findModel(models, "Item")
+	if item == nil {
+		t.Fatalf("expected model Item to be generated")
+	}
+	want := map[string]string{
+		"Name":   `"un\"named"`,
+		"Count":  "3",
+		"Ratio":  "0.5",
+		"Active": "false",
+		"Labels": `[]string{"x", "y"}`,
+		"Meta":   "",
+		"Plain":  "",
+	}
+	for _, field := range item.Fields {
+		if def, ok := want[field.GoName]; ok && field.Default != def {
+			t.Errorf("field %s: expected default %q, got %q", field.GoName, def, field.Default)
+		}
+	}
+
+	ApplyDefaultsOnUnmarshal(models)
+	if !item.UnmarshalDefaults || !slices.Contains(item.Imports, "encoding/json") {
+		t.Errorf("expected Item to unmarshal defaults, got %+v", item)
+	}
+}
+
+func TestMapAPIFromPaths_Parameters(t *testing.T) {
+	doc := loadDoc(t, `
+openapi: 3.0.0
+info: {title: t, version: "1"}
+paths:
+  /items/{id}:
+    parameters:
+      - {name: id, in: path, required: true, schema: {type: integer}}
+    get:
+      operationId: getItem
+      tags: [item]
+      parameters:
+        - {name: limit, in: query, schema: {type: integer, default: 20}}
+        - {name: kinds, in: query, schema: {type: array, items: {type: string}, default: [a, b]}}
+        - {name: X-Trace, in: header, schema: {type: string, default: none}}
+        - {name: session, in: cookie, schema: {type: integer, default: 1}}
+        - name: filter
+          in: query
+          schema: {type: object, properties: {name: {type: string}}}
+      responses:
+        "204": {description: ok}
+components: {}
+`)
+	api := findAPIByOperationID(MapAPIFromPaths(doc), "item", "GetItem")
+	if api == nil {
+		t.Fatalf("expected API GetItem")
+	}
+	want := []templates.Parameter{
+		{Name: "id", GoName: "Id", GoType: "int", In: "path", Required: true, Tag: `uri:"id" form:"-" header:"-" cookie:"-"`},
+		{Name: "filter", GoName: "Filter", GoType: "GetItemFilterParam", In: "query", Tag: `uri:"-" form:"filter" header:"-" cookie:"-"`},
+		{Name: "kinds", GoName: "Kinds", GoType: "[]string", In: "query", Default: "a;b", Tag: `uri:"-" form:"kinds,default=a;b" header:"-" cookie:"-"`},
+		{Name: "limit", GoName: "Limit", GoType: "int", In: "query", Default: "20", Tag: `uri:"-" form:"limit,default=20" header:"-" cookie:"-"`},
+		{Name: "X-Trace", GoName: "XTrace", GoType: "string", In: "header", Default: "none", Tag: `uri:"-" form:"-" header:"X-Trace,default=none" cookie:"-"`},
+		{Name: "session", GoName: "Session", GoType: "int", In: "cookie", Default: "1", Tag: `uri:"-" form:"-" header:"-" cookie:"session,default=1"`},
+	}
+	if !slices.Equal(api.Parameters, want) {
+		t.Errorf("expected parameters %+v, got %+v", want, api.Parameters)
+	}
+	if findModel(MapModelsFromPaths(doc), "GetItemFilterParam") == nil {
+		t.Errorf("expected model GetItemFilterParam to be generated")
+	}
+}
@@ -0,0 +1,111 @@
+package mapper
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	"sort"
+)
+
+// Vendor extensions understood by the mapper.
+const (
+	// extGoType substitutes an existing Go type for the schema.
+	extGoType = "x-go-type"
+	// extGoTypeImport is the import path of x-go-type, either a string or an
+	// object with path and an optional alias name.
+	extGoTypeImport = "x-go-type-import"
+	// extGoName overrides the Go identifier of a schema, property or operation.
+	extGoName = "x-go-name"
+	// extOmitEmpty adds omitempty to the json tag of a property.
+	extOmitEmpty = "x-omitempty"
+	// extJSONIgnore tags a property json:"-".
+	extJSONIgnore = "x-go-json-ignore"
+	// extSkip excludes a schema, property or operation from generation.
+	extSkip = "x-go-skip"
+)
+
+func extString(ext map[string]any, key string) string {
+	s, _ := ext[key].(string)
+	return s
+}
+
+func extBool(ext map[string]any, key string) bool {
+	b, _ := ext[key].(bool)
+	return b
+}
+
+// goTypeImport returns the import declared by x-go-type-import as "path" or
+// "alias path".
+func goTypeImport(ext map[string]any) string {
+	switch v := ext[extGoTypeImport].(type) {
+	case string:
+		return v
+	case map[string]any:
+		path, _ := v["path"].(string)
+		if name, _ := v["name"].(string); name != "" && path != "" {
+			return name + " " + path
+		}
+		return path
+	}
+	return ""
+}
+
+// schemaImports collects the x-go-type-import of schema and of the array
+// items and map values it wraps.
+func schemaImports(schema *openapi3.SchemaRef) []string {
+	if schema == nil || schema.Value == nil {
+		return nil
+	}
+	if extString(schema.Value.Extensions, extGoType) != "" {
+		if imp := goTypeImport(schema.Value.Extensions); imp != "" {
+			return []string{imp}
+		}
+		return nil
+	}
+	if isType(schema.Value, openapi3.TypeArray) {
+		return schemaImports(schema.Value.Items)
+	}
+	if isType(schema.Value, openapi3.TypeObject) && len(schema.Value.Properties) == 0 {
+		return schemaImports(schema.Value.AdditionalProperties.Schema)
+	}
+	return nil
+}
+
+// operationImports collects the x-go-type-import of the parameter, request
+// and response body schemas of operation.
+func operationImports(operation *openapi3.Operation) []string {
+	var imports []string
+	for _, param := range operation.Parameters {
+		if param.Value != nil {
+			imports = append(imports, schemaImports(param.Value.Schema)...)
+		}
+	}
+	if operation.RequestBody != nil && operation.RequestBody.Value != nil {
+		for _, media := range operation.RequestBody.Value.Content {
+			imports = append(imports, schemaImports(media.Schema)...)
+		}
+	}
+	if operation.Responses != nil {
+		for _, response := range operation.Responses.Map() {
+			if response.Value == nil {
+				continue
+			}
+			for _, media := range response.Value.Content {
+				imports = append(imports, schemaImports(media.Schema)...)
+			}
+		}
+	}
+	return uniqueSorted(imports)
+}
+
+func uniqueSorted(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Strings(values)
+	unique := values[:1]
+	for _, v := range values[1:] {
+		if v != unique[len(unique)-1] {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,116 @@
+package mapper
+
+import (
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func TestMapModelsFromSchemas_Extensions(t *testing.T) {
+	str := &openapi3.Types{openapi3.TypeString}
+	timestamp := &openapi3.SchemaRef{
+		Ref: "#/components/schemas/Timestamp",
+		Value: &openapi3.Schema{Type: str, Extensions: map[string]any{
+			"x-go-type":        "time.Time",
+			"x-go-type-import": "time",
+		}},
+	}
+	addr := &openapi3.SchemaRef{Value: &openapi3.Schema{Type: str, Extensions: map[string]any{
+		"x-go-type":        "nip.Addr",
+		"x-go-type-import": map[string]any{"path": "net/netip", "name": "nip"},
+	}}}
+	doc := &openapi3.T{Components: &openapi3.Components{Schemas: openapi3.Schemas{
+		"Timestamp": timestamp,
+		"Skipped": {Value: &openapi3.Schema{
+			Type:       &openapi3.Types{openapi3.TypeObject},
+			Extensions: map[string]any{"x-go-skip": true},
+		}},
+		"Event": {Value: &openapi3.Schema{
+			Type:       &openapi3.Types{openapi3.TypeObject},
+			Extensions: map[string]any{"x-go-name": "EventRecord"},
+			Properties: openapi3.Schemas{
+				"at":       timestamp,
+				"from":     addr,
+				"id":       {Value: &openapi3.Schema{Type: str, Extensions: map[string]any{"x-go-name": "ID"}}},
+				"note":     {Value: &openapi3.Schema{Type: str, Extensions: map[string]any{"x-omitempty": true}}},
+				"secret":   {Value: &openapi3.Schema{Type: str, Extensions: map[string]any{"x-go-json-ignore": true}}},
+				"internal": {Value: &openapi3.Schema{Type: str, Extensions: map[string]any{"x-go-skip": true}}},
+			},
+		}},
+	}}}
+
+	models := MapModelsFromSchemas(doc)
+	if len(models) != 1 {
+		t.Fatalf("expected only EventRecord to be generated, got %d models", len(models))
+	}
+	event := findModel(models, "EventRecord")
+	if event == nil {
+		t.Fatalf("expected model EventRecord to be generated")
+	}
+	if event.OriginalName != "Event" {
+		t.Errorf("expected OriginalName Event, got %q", event.OriginalName)
+	}
+	assertField(t, event.Fields, "At", "time.Time", "at")
+	assertField(t, event.Fields, "From", "nip.Addr", "from")
+	assertField(t, event.Fields, "ID", "string", "id")
+	if len(event.Fields) != 5 {
+		t.Errorf("expected internal to be skipped, got %d fields", len(event.Fields))
+	}
+	wantImports := []string{"nip net/netip", "time"}
+	if len(event.Imports) != 2 || event.Imports[0] != wantImports[0] || event.Imports[1] != wantImports[1] {
+		t.Errorf("expected imports %v, got %v", wantImports, event.Imports)
+	}
+
+	tags := map[string]string{}
+	for _, f := range event.Fields {
+		tags[f.GoName] = f.Tags
+	}
+	if tags["Note"] != `json:"note,omitempty"` {
+		t.Errorf("expected omitempty json tag for Note, got %s", tags["Note"])
+	}
+	if tags["Secret"] != `json:"-"` {
+		t.Errorf("expected ignored json tag for Secret, got %s", tags["Secret"])
+	}
+}
+
+func TestMapAPIFromPaths_Extensions(t *testing.T) {
+	doc := &openapi3.T{Components: &openapi3.Components{}}
+	doc.Paths = openapi3.NewPaths()
+
+	list := &openapi3.Operation{
+		OperationID: "listEvents",
+		Tags:        []string{"events"},
+		Extensions:  map[string]any{"x-go-name": "ListAllEvents"},
+		Responses:   openapi3.NewResponses(),
+	}
+	list.Responses.Set("200", &openapi3.ResponseRef{Value: &openapi3.Response{
+		Content: openapi3.Content{"application/json": {Schema: &openapi3.SchemaRef{
+			Ref: "#/components/schemas/Timestamp",
+			Value: &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}, Extensions: map[string]any{
+				"x-go-type":        "time.Time",
+				"x-go-type-import": "time",
+			}},
+		}}},
+	}})
+	hidden := &openapi3.Operation{
+		OperationID: "hidden",
+		Tags:        []string{"events"},
+		Extensions:  map[string]any{"x-go-skip": true},
+	}
+	doc.Paths.Set("/events", &openapi3.PathItem{Get: list, Post: hidden})
+
+	apis := MapAPIFromPaths(doc)
+	if len(apis["events"]) != 1 {
+		t.Fatalf("expected skipped operation to be excluded, got %d operations", len(apis["events"]))
+	}
+	api := findAPIByOperationID(apis, "events", "ListAllEvents")
+	if api == nil {
+		t.Fatalf("expected operation to be renamed ListAllEvents")
+	}
+	if api.Response == nil || api.Response.ModelName != "time.Time" || api.Response.TypeName != "ListAllEvents200Response" {
+		t.Errorf("unexpected response: %+v", api.Response)
+	}
+	if len(api.Imports) != 1 || api.Imports[0] != "time" {
+		t.Errorf("expected time import, got %v", api.Imports)
+	}
+}
@@ -0,0 +1,187 @@
+package mapper
+
+import (
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopenapi/internal/config"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// FilterDocument removes from doc the operations and component schemas
+// filter does not select, so that the mappers only see the selected slice
+// of the spec. Excluded schemas that selected operations or schemas still
+// refer to are kept, and a warning is returned for each of them.
+func FilterDocument(doc *openapi3.T, filter config.Filter) []string {
+	if filter.Include.IsEmpty() && filter.Exclude.IsEmpty() {
+		return nil
+	}
+	if doc.Paths != nil {
+		for path, item := range doc.Paths.Map() {
+			for method, operation := range item.Operations() {
+				if !operationSelected(path, operation, filter) {
+					item.SetOperation(method, nil)
+				}
+			}
+			if len(item.Operations()) == 0 {
+				doc.Paths.Delete(path)
+			}
+		}
+	}
+	if doc.Components == nil {
+		return nil
+	}
+
+	reach := reachability{names: map[*openapi3.Schema]string{}, seen: map[*openapi3.Schema]bool{}, used: map[string]bool{}}
+	for name, schema := range doc.Components.Schemas {
+		if schema.Value != nil {
+			reach.names[schema.Value] = name
+		}
+	}
+	for _, name := range filter.Include.Schemas {
+		reach.schema(doc.Components.Schemas[name])
+	}
+	if doc.Paths != nil {
+		for _, item := range doc.Paths.Map() {
+			for _, param := range item.Parameters {
+				reach.parameter(param)
+			}
+			for _, operation := range item.Operations() {
+				reach.operation(operation)
+			}
+		}
+	}
+
+	var warnings []string
+	for name := range doc.Components.Schemas {
+		switch {
+		case slices.Contains(filter.Exclude.Schemas, name):
+			if reach.used[name] {
+				// Dropping it would leave the generated code referring to a
+				// type that does not exist, so the schema is kept.
+				warnings = append(warnings, fmt.Sprintf("schema %s is excluded but still referenced, the exclusion is ignored", name))
+				continue
+			}
+			delete(doc.Components.Schemas, name)
+		case !reach.used[name]:
+			delete(doc.Components.Schemas, name)
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+func operationSelected(path string, operation *openapi3.Operation, filter config.Filter) bool {
+	include := filter.Include
+	if len(include.Tags)+len(include.OperationIDs)+len(include.Paths) > 0 && !operationMatches(path, operation, include) {
+		return false
+	}
+	return !operationMatches(path, operation, filter.Exclude)
+}
+
+func operationMatches(path string, operation *openapi3.Operation, set config.FilterSet) bool {
+	for _, tag := range operation.Tags {
+		if slices.ContainsFunc(set.Tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			return true
+		}
+	}
+	if slices.Contains(set.OperationIDs, operation.OperationID) {
+		return true
+	}
+	return slices.ContainsFunc(set.Paths, func(glob string) bool { return globMatch(glob, path) })
+}
+
+// globMatch matches a path against a glob where * matches within a path
+// segment and ** across segments.
+func globMatch(glob, path string) bool {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			expr.WriteString("[^/]*")
+		case glob[i] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	expr.WriteString("$")
+	matched, _ := regexp.MatchString(expr.String(), path)
+	return matched
+}
+
+// reachability collects the component schemas referred to, directly or
+// through other schemas, by the parts of the document it visits.
+type reachability struct {
+	names map[*openapi3.Schema]string
+	seen  map[*openapi3.Schema]bool
+	used  map[string]bool
+}
+
+func (r *reachability) operation(operation *openapi3.Operation) {
+	for _, param := range operation.Parameters {
+		r.parameter(param)
+	}
+	if operation.RequestBody != nil && operation.RequestBody.Value != nil {
+		r.content(operation.RequestBody.Value.Content)
+	}
+	if operation.Responses != nil {
+		for _, resp := range operation.Responses.Map() {
+			if resp.Value == nil {
+				continue
+			}
+			r.content(resp.Value.Content)
+			for _, header := range resp.Value.Headers {
+				if header.Value != nil {
+					r.schema(header.Value.Schema)
+				}
+			}
+		}
+	}
+}
+
+func (r *reachability) parameter(param *openapi3.ParameterRef) {
+	if param != nil && param.Value != nil {
+		r.schema(param.Value.Schema)
+		r.content(param.Value.Content)
+	}
+}
+
+func (r *reachability) content(content openapi3.Content) {
+	for _, media := range content {
+		if media != nil {
+			r.schema(media.Schema)
+		}
+	}
+}
+
+func (r *reachability) schema(ref *openapi3.SchemaRef) {
+	if ref == nil || ref.Value == nil || r.seen[ref.Value] {
+		return
+	}
+	schema := ref.Value
+	r.seen[schema] = true
+	if name, ok := r.names[schema]; ok {
+		r.used[name] = true
+	}
+	if strings.HasPrefix(ref.Ref, "#/components/schemas/") {
+		r.used[strings.SplitN(strings.TrimPrefix(ref.Ref, "#/components/schemas/"), "/", 2)[0]] = true
+	}
+	for _, prop := range schema.Properties {
+		r.schema(prop)
+	}
+	r.schema(schema.Items)
+	r.schema(schema.AdditionalProperties.Schema)
+	r.schema(schema.Not)
+	for _, refs := range []openapi3.SchemaRefs{schema.AllOf, schema.AnyOf, schema.OneOf} {
+		for _, sub := range refs {
+			r.schema(sub)
+		}
+	}
+}
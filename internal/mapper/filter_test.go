@@ -0,0 +1,170 @@
+package mapper
+
+import (
+	"slices"
+	"sort"
+	"testing"
+
+	"gopenapi/internal/config"
+)
+
+const filterSpec = `
+openapi: 3.0.0
+info: {title: t, version: "1"}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      tags: [Pets]
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: array
+                items: {$ref: '#/components/schemas/Pet'}
+    post:
+      operationId: addPet
+      tags: [Pets]
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/NewPet'}
+      responses:
+        "204": {description: ok}
+  /users/{id}/orders:
+    get:
+      operationId: listOrders
+      tags: [users]
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: string}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/Order'}
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        owner: {$ref: '#/components/schemas/Owner'}
+    Owner:
+      type: object
+      properties:
+        name: {type: string}
+    NewPet:
+      type: object
+      properties:
+        name: {type: string}
+    Order:
+      type: object
+      properties:
+        id: {type: string}
+    Unused:
+      type: object
+      properties:
+        id: {type: string}
+`
+
+func TestFilterDocument(t *testing.T) {
+	tests := []struct {
+		name       string
+		filter     config.Filter
+		operations []string
+		schemas    []string
+		warnings   []string
+	}{
+		{
+			name:       "no filter",
+			operations: []string{"addPet", "listOrders", "listPets"},
+			schemas:    []string{"NewPet", "Order", "Owner", "Pet", "Unused"},
+		},
+		{
+			name:       "include tag",
+			filter:     config.Filter{Include: config.FilterSet{Tags: []string{"pets"}}},
+			operations: []string{"addPet", "listPets"},
+			schemas:    []string{"NewPet", "Owner", "Pet"},
+		},
+		{
+			name:       "include tag exclude operation",
+			filter:     config.Filter{Include: config.FilterSet{Tags: []string{"pets"}}, Exclude: config.FilterSet{OperationIDs: []string{"addPet"}}},
+			operations: []string{"listPets"},
+			schemas:    []string{"Owner", "Pet"},
+		},
+		{
+			name:       "include path glob",
+			filter:     config.Filter{Include: config.FilterSet{Paths: []string{"/users/*/orders"}}},
+			operations: []string{"listOrders"},
+			schemas:    []string{"Order"},
+		},
+		{
+			name:       "exclude path glob",
+			filter:     config.Filter{Exclude: config.FilterSet{Paths: []string{"/users/**"}}},
+			operations: []string{"addPet", "listPets"},
+			schemas:    []string{"NewPet", "Owner", "Pet"},
+		},
+		{
+			name:       "include schema",
+			filter:     config.Filter{Include: config.FilterSet{OperationIDs: []string{"listOrders"}, Schemas: []string{"Unused"}}},
+			operations: []string{"listOrders"},
+			schemas:    []string{"Order", "Unused"},
+		},
+		{
+			name:       "exclude referenced schema",
+			filter:     config.Filter{Exclude: config.FilterSet{Schemas: []string{"Owner", "Unused"}}},
+			operations: []string{"addPet", "listOrders", "listPets"},
+			schemas:    []string{"NewPet", "Order", "Owner", "Pet"},
+			warnings:   []string{"schema Owner is excluded but still referenced, the exclusion is ignored"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := loadDoc(t, filterSpec)
+			warnings := FilterDocument(doc, tt.filter)
+
+			var operations, schemas []string
+			for _, item := range doc.Paths.Map() {
+				for _, operation := range item.Operations() {
+					operations = append(operations, operation.OperationID)
+				}
+			}
+			for name := range doc.Components.Schemas {
+				schemas = append(schemas, name)
+			}
+			sort.Strings(operations)
+			sort.Strings(schemas)
+			if !slices.Equal(operations, tt.operations) {
+				t.Errorf("expected operations %v, got %v", tt.operations, operations)
+			}
+			if !slices.Equal(schemas, tt.schemas) {
+				t.Errorf("expected schemas %v, got %v", tt.schemas, schemas)
+			}
+			if !slices.Equal(warnings, tt.warnings) {
+				t.Errorf("expected warnings %q, got %q", tt.warnings, warnings)
+			}
+		})
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		glob, path string
+		want       bool
+	}{
+		{"/pets", "/pets", true},
+		{"/pets/*", "/pets/{id}", true},
+		{"/pets/*", "/pets/{id}/photos", false},
+		{"/pets/**", "/pets/{id}/photos", true},
+		{"/users/*/orders", "/users/{id}/orders", true},
+		{"/pet?", "/pets", true},
+		{"/pets.json", "/petsxjson", false},
+	}
+	for _, tt := range tests {
+		if got := globMatch(tt.glob, tt.path); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.glob, tt.path, got, tt.want)
+		}
+	}
+}
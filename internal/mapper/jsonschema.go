@@ -0,0 +1,440 @@
+package mapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/oasdiff/yaml"
+	"gopenapi/internal/templates"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// JSON Schema 2020-12 keywords used by OpenAPI 3.1 documents. The loader
+// does not model them and keeps them in the extensions of a schema.
+const (
+	kwConst            = "const"
+	kwPrefixItems      = "prefixItems"
+	kwDefs             = "$defs"
+	kwExamples         = "examples"
+	kwContentEncoding  = "contentEncoding"
+	kwContentMediaType = "contentMediaType"
+	kwIf               = "if"
+	kwThen             = "then"
+	kwElse             = "else"
+)
+
+// unsupportedKeywords are reported by Diagnose and otherwise ignored.
+var unsupportedKeywords = []string{
+	"$anchor", "$dynamicAnchor", "$dynamicRef", "contains", "dependentRequired",
+	"dependentSchemas", "maxContains", "minContains", "patternProperties",
+	"propertyNames", "unevaluatedItems", "unevaluatedProperties",
+}
+
+// NormalizeExclusiveBounds rewrites the numeric exclusiveMinimum and
+// exclusiveMaximum of JSON Schema 2020-12, which the loader cannot decode,
+// into the minimum and maximum with the boolean flag of OpenAPI 3.0. data
+// is returned as is, YAML or JSON, when it has none.
+func NormalizeExclusiveBounds(data []byte) ([]byte, error) {
+	converted, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(converted, &doc); err != nil {
+		return nil, err
+	}
+	if !normalizeBounds(doc) {
+		return data, nil
+	}
+	return json.Marshal(doc)
+}
+
+// normalizeBounds rewrites the numeric exclusive bounds of the objects in v
+// and reports whether it found any. When the inclusive bound is the tighter
+// one the exclusive bound is dropped.
+func normalizeBounds(v any) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		for _, bound := range []struct {
+			exclusive, inclusive string
+			tighter              func(a, b float64) bool
+		}{
+			{"exclusiveMinimum", "minimum", func(a, b float64) bool { return a > b }},
+			{"exclusiveMaximum", "maximum", func(a, b float64) bool { return a < b }},
+		} {
+			limit, ok := v[bound.exclusive].(float64)
+			if !ok {
+				continue
+			}
+			changed = true
+			if inclusive, ok := v[bound.inclusive].(float64); ok && bound.tighter(inclusive, limit) {
+				delete(v, bound.exclusive)
+				continue
+			}
+			v[bound.inclusive], v[bound.exclusive] = limit, true
+		}
+		for _, value := range v {
+			changed = normalizeBounds(value) || changed
+		}
+	case []any:
+		for _, value := range v {
+			changed = normalizeBounds(value) || changed
+		}
+	}
+	return changed
+}
+
+// typeOf returns the type of a schema: the only non-null entry of a type
+// array, or the type of its const value. Schemas allowing several types
+// have none.
+func typeOf(schema *openapi3.Schema) string {
+	var types []string
+	if schema.Type != nil {
+		for _, t := range *schema.Type {
+			if t != openapi3.TypeNull {
+				types = append(types, t)
+			}
+		}
+	}
+	switch len(types) {
+	case 0:
+		return constType(schema.Extensions[kwConst])
+	case 1:
+		return types[0]
+	}
+	return ""
+}
+
+func isType(schema *openapi3.Schema, typ string) bool {
+	return schema != nil && typeOf(schema) == typ
+}
+
+// isNullable reports whether a schema allows null, with nullable or a null
+// entry in its type array.
+func isNullable(schema *openapi3.Schema) bool {
+	return schema.Nullable || schema.Type != nil && slices.Contains(*schema.Type, openapi3.TypeNull)
+}
+
+// isScalar reports whether goType is one of the Go types of string, number,
+// integer and boolean schemas.
+func isScalar(goType string) bool {
+	switch goType {
+	case "string", "int", "float64", "bool":
+		return true
+	}
+	return false
+}
+
+func constType(v any) string {
+	switch v := v.(type) {
+	case string:
+		return openapi3.TypeString
+	case bool:
+		return openapi3.TypeBoolean
+	case float64:
+		if v == float64(int64(v)) {
+			return openapi3.TypeInteger
+		}
+		return openapi3.TypeNumber
+	}
+	return ""
+}
+
+// stringType maps a string schema to []byte when its content is base64
+// encoded, with format byte or contentEncoding base64, which encoding/json
+// decodes transparently.
+func stringType(schema *openapi3.Schema) string {
+	if schema.Format == "byte" || extString(schema.Extensions, kwContentEncoding) == "base64" {
+		return "[]byte"
+	}
+	return "string"
+}
+
+// tupleType maps prefixItems to a slice of their Go type when every item,
+// including those allowed by items, has the same one.
+func tupleType(name string, schema *openapi3.Schema, models *[]templates.Model, visiting map[*openapi3.Schema]string) string {
+	prefixItems, _ := schema.Extensions[kwPrefixItems].([]any)
+	var goTypes []string
+	for i, raw := range prefixItems {
+		goTypes = append(goTypes, subSchemaType(fmt.Sprintf("%sItem%d", name, i), raw, models, visiting))
+	}
+	if schema.Items != nil {
+		goTypes = append(goTypes, parseSchemaVisiting(name+"Item", schema.Items, models, visiting))
+	}
+	for _, goType := range goTypes {
+		if goType != goTypes[0] {
+			return "[]interface{}"
+		}
+	}
+	if len(goTypes) == 0 {
+		return "[]interface{}"
+	}
+	return "[]" + goTypes[0]
+}
+
+// subSchemaType returns the Go type of a schema kept raw in the extensions.
+// Its references are not resolved, only components are referred to by name.
+func subSchemaType(name string, raw any, models *[]templates.Model, visiting map[*openapi3.Schema]string) string {
+	ref := subSchema(raw)
+	if ref == nil {
+		return "interface{}"
+	}
+	if ref.Ref != "" {
+		if strings.HasPrefix(ref.Ref, "#/components/schemas/") && !strings.Contains(ref.Ref, "/"+kwDefs+"/") {
+			return refName(ref.Ref)
+		}
+		return "interface{}"
+	}
+	return parseSchemaVisiting(name, ref, models, visiting)
+}
+
+func subSchema(raw any) *openapi3.SchemaRef {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var ref openapi3.SchemaRef
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return nil
+	}
+	return &ref
+}
+
+// fieldDescription returns the description of a property followed by its
+// first example.
+func fieldDescription(schema *openapi3.Schema) string {
+	example := schema.Example
+	if examples, ok := schema.Extensions[kwExamples].([]any); ok && len(examples) > 0 {
+		example = examples[0]
+	}
+	if example == nil {
+		return schema.Description
+	}
+	data, err := json.Marshal(example)
+	if err != nil {
+		return schema.Description
+	}
+	if schema.Description == "" {
+		return "Example: " + string(data)
+	}
+	return strings.TrimSpace(schema.Description) + " Example: " + string(data)
+}
+
+// mapConditions translates if/then/else into checks of the Validate method.
+// Only conditions on const properties and required lists are supported,
+// others are reported by Diagnose.
+func mapConditions(schema *openapi3.Schema, fields []templates.ModelProp) []templates.Condition {
+	if !conditionSupported(schema.Extensions) {
+		return nil
+	}
+	byJSONName := map[string]templates.ModelProp{}
+	for _, field := range fields {
+		byJSONName[field.JSONName] = field
+	}
+	cond, _ := schema.Extensions[kwIf].(map[string]any)
+	var checks []string
+	props, _ := cond["properties"].(map[string]any)
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field, ok := byJSONName[name]
+		if !ok {
+			return nil
+		}
+		literal := scalarLiteral(props[name].(map[string]any)[kwConst], field.GoType)
+		if literal == "" {
+			return nil
+		}
+		checks = append(checks, fmt.Sprintf("m.%s == %s", field.GoName, literal))
+	}
+	for _, name := range stringList(cond["required"]) {
+		field, ok := byJSONName[name]
+		if !ok {
+			return nil
+		}
+		checks = append(checks, fmt.Sprintf("!isZero(m.%s)", field.GoName))
+	}
+	if len(checks) == 0 {
+		return nil
+	}
+	requiredFields := func(key string) ([]templates.ModelProp, bool) {
+		branch, _ := schema.Extensions[key].(map[string]any)
+		var required []templates.ModelProp
+		for _, name := range stringList(branch["required"]) {
+			field, ok := byJSONName[name]
+			if !ok {
+				return nil, false
+			}
+			required = append(required, field)
+		}
+		return required, true
+	}
+	then, okThen := requiredFields(kwThen)
+	otherwise, okElse := requiredFields(kwElse)
+	if !okThen || !okElse || len(then)+len(otherwise) == 0 {
+		return nil
+	}
+	return []templates.Condition{{If: strings.Join(checks, " && "), Then: then, Else: otherwise}}
+}
+
+// conditionSupported reports whether the if/then/else of a schema only
+// tests const properties and required lists, and only requires properties.
+func conditionSupported(ext map[string]any) bool {
+	cond, ok := ext[kwIf].(map[string]any)
+	if !ok {
+		return false
+	}
+	for key, value := range cond {
+		switch key {
+		case "required":
+		case "properties":
+			props, ok := value.(map[string]any)
+			if !ok {
+				return false
+			}
+			for _, prop := range props {
+				prop, ok := prop.(map[string]any)
+				if _, isConst := prop[kwConst]; !ok || !isConst || len(prop) != 1 {
+					return false
+				}
+			}
+		default:
+			return false
+		}
+	}
+	for _, key := range []string{kwThen, kwElse} {
+		branch, ok := ext[key]
+		if !ok {
+			continue
+		}
+		branchMap, ok := branch.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k := range branchMap {
+			if k != "required" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func stringList(v any) []string {
+	items, _ := v.([]any)
+	var list []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// Diagnose reports the schema constructs of doc the generated code does not
+// represent faithfully, sorted by location.
+func Diagnose(doc *openapi3.T) []string {
+	d := diagnoser{visited: map[*openapi3.Schema]bool{}}
+	if doc.Components != nil {
+		for name, schema := range doc.Components.Schemas {
+			d.schema("#/components/schemas/"+name, schema)
+		}
+	}
+	if doc.Paths != nil {
+		for path, item := range doc.Paths.Map() {
+			for method, operation := range item.Operations() {
+				at := fmt.Sprintf("%s %s", strings.ToUpper(method), path)
+				for _, param := range append(item.Parameters, operation.Parameters...) {
+					if param.Value != nil {
+						d.parameter(at, param.Value)
+						d.schema(at+" parameter "+param.Value.Name, param.Value.Schema)
+					}
+				}
+				if operation.RequestBody != nil && operation.RequestBody.Value != nil {
+					for mediaType, media := range operation.RequestBody.Value.Content {
+						d.schema(at+" request "+mediaType, media.Schema)
+					}
+				}
+				if operation.Responses != nil {
+					for status, resp := range operation.Responses.Map() {
+						if resp.Value == nil {
+							continue
+						}
+						for mediaType, media := range resp.Value.Content {
+							d.schema(at+" response "+status+" "+mediaType, media.Schema)
+						}
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(d.diagnostics)
+	return d.diagnostics
+}
+
+type diagnoser struct {
+	visited     map[*openapi3.Schema]bool
+	diagnostics []string
+}
+
+func (d *diagnoser) report(at, format string, args ...any) {
+	d.diagnostics = append(d.diagnostics, at+": "+fmt.Sprintf(format, args...))
+}
+
+func (d *diagnoser) schema(at string, ref *openapi3.SchemaRef) {
+	if ref == nil || ref.Value == nil || d.visited[ref.Value] {
+		return
+	}
+	if ref.Ref != "" {
+		at = ref.Ref
+	}
+	schema := ref.Value
+	d.visited[schema] = true
+
+	if schema.Type != nil && len(*schema.Type) > 1 && typeOf(schema) == "" {
+		d.report(at, "type %v is mapped to interface{}", []string(*schema.Type))
+	}
+	if _, ok := schema.Extensions[kwPrefixItems]; ok {
+		var discard []templates.Model
+		if tupleType(at, schema, &discard, map[*openapi3.Schema]string{}) == "[]interface{}" {
+			d.report(at, "prefixItems of different types are mapped to []interface{}")
+		}
+	}
+	if enc := extString(schema.Extensions, kwContentEncoding); enc != "" && enc != "base64" {
+		d.report(at, "contentEncoding %s is kept as string", enc)
+	}
+	for _, value := range schema.Enum {
+		if _, ok := oneofValue(value); value != nil && !ok {
+			d.report(at, "enum value %q cannot be written in a validate tag, only Validate checks the enum", fmt.Sprint(value))
+			break
+		}
+	}
+	if _, ok := schema.Extensions[kwIf]; ok && !conditionSupported(schema.Extensions) {
+		d.report(at, "if/then/else is only checked for const properties and required lists")
+	}
+	for _, keyword := range unsupportedKeywords {
+		if _, ok := schema.Extensions[keyword]; ok {
+			d.report(at, "%s is not supported", keyword)
+		}
+	}
+
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		d.schema(at+"/properties/"+name, schema.Properties[name])
+	}
+	d.schema(at+"/items", schema.Items)
+	d.schema(at+"/additionalProperties", schema.AdditionalProperties.Schema)
+	for i, sub := range schema.AllOf {
+		d.schema(fmt.Sprintf("%s/allOf/%d", at, i), sub)
+	}
+}
@@ -0,0 +1,197 @@
+package mapper
+
+import (
+	"slices"
+	"testing"
+
+	"gopenapi/internal/templates"
+)
+
+func TestMapModelsFromSchemas_JSONSchema2020(t *testing.T) {
+	tests := []struct {
+		name        string
+		property    string
+		goType      string
+		description string
+		validations []templates.Validation
+	}{
+		{name: "nullable type array", property: `{type: [string, "null"]}`, goType: "*string"},
+		{name: "null first", property: `{type: ["null", integer]}`, goType: "*int"},
+		{name: "nullable", property: `{type: boolean, nullable: true}`, goType: "*bool"},
+		{name: "nullable array", property: `{type: [array, "null"], items: {type: string}}`, goType: "[]string"},
+		{name: "nullable with constraints", property: `{type: [string, "null"], maxLength: 3}`, goType: "*string",
+			validations: []templates.Validation{{Func: "checkMaxLength", Args: "3"}}},
+		{name: "nullable enum", property: `{type: [string, "null"], enum: [a, null]}`, goType: "*string",
+			validations: []templates.Validation{{Func: "checkEnum", Args: `"a"`}}},
+		{name: "numeric exclusive bounds", property: `{type: integer, exclusiveMinimum: 0, exclusiveMaximum: 10}`, goType: "int",
+			validations: []templates.Validation{{Func: "checkMinimum", Args: "0, true"}, {Func: "checkMaximum", Args: "10, true"}}},
+		{name: "tighter minimum", property: `{type: number, minimum: 5, exclusiveMinimum: 1}`, goType: "float64",
+			validations: []templates.Validation{{Func: "checkMinimum", Args: "5, false"}}},
+		{name: "several types", property: `{type: [string, integer]}`, goType: "interface{}"},
+		{name: "string const", property: `{const: fixed}`, goType: "string",
+			validations: []templates.Validation{{Func: "checkEnum", Args: `"fixed"`}}},
+		{name: "integer const", property: `{const: 3}`, goType: "int",
+			validations: []templates.Validation{{Func: "checkEnum", Args: "3"}}},
+		{name: "uniform prefixItems", property: `{type: array, prefixItems: [{type: number}, {type: number}]}`, goType: "[]float64"},
+		{name: "mixed prefixItems", property: `{type: array, prefixItems: [{type: string}, {type: integer}]}`, goType: "[]interface{}"},
+		{name: "prefixItems and items", property: `{type: array, prefixItems: [{type: string}], items: {type: integer}}`, goType: "[]interface{}"},
+		{name: "examples", property: `{type: string, description: A name., examples: [bob, alice]}`, goType: "string",
+			description: `A name. Example: "bob"`},
+		{name: "base64 content", property: `{type: string, contentEncoding: base64}`, goType: "[]byte"},
+		{name: "byte format", property: `{type: string, format: byte}`, goType: "[]byte"},
+		{name: "other content encoding", property: `{type: string, contentEncoding: base32}`, goType: "string"},
+		{name: "content media type", property: `{type: string, contentMediaType: image/png}`, goType: "string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := loadDoc(t, `
+openapi: 3.1.0
+info: {title: t, version: "1"}
+paths: {}
+components:
+  schemas:
+    Thing:
+      type: object
+      properties:
+        prop: `+tt.property+`
+`)
+			thing := findModel(MapModelsFromSchemas(doc), "Thing")
+			if thing == nil {
+				t.Fatalf("expected model Thing to be generated")
+			}
+			assertField(t, thing.Fields, "Prop", tt.goType, "prop")
+			assertValidations(t, thing.Fields, "Prop", tt.validations)
+			if thing.Fields[0].Description != tt.description {
+				t.Errorf("expected description %q, got %q", tt.description, thing.Fields[0].Description)
+			}
+		})
+	}
+}
+
+func TestMapModelsFromSchemas_Conditions(t *testing.T) {
+	doc := loadDoc(t, `
+openapi: 3.1.0
+info: {title: t, version: "1"}
+paths: {}
+components:
+  schemas:
+    Limits:
+      type: object
+      properties:
+        mode: {type: string}
+        limit: {type: integer}
+        name: {type: string}
+      if: {properties: {mode: {const: fixed}}, required: [mode]}
+      then: {required: [limit]}
+      else: {required: [name]}
+    Unsupported:
+      type: object
+      properties:
+        mode: {type: string}
+        limit: {type: integer}
+      if: {properties: {mode: {pattern: "^f"}}}
+      then: {required: [limit]}
+`)
+	models := MapModelsFromSchemas(doc)
+	limits := findModel(models, "Limits")
+	if limits == nil || len(limits.Conditions) != 1 {
+		t.Fatalf("expected one condition on Limits, got %+v", limits)
+	}
+	cond := limits.Conditions[0]
+	if cond.If != `m.Mode == "fixed" && !isZero(m.Mode)` {
+		t.Errorf("unexpected condition %q", cond.If)
+	}
+	if len(cond.Then) != 1 || cond.Then[0].GoName != "Limit" || len(cond.Else) != 1 || cond.Else[0].GoName != "Name" {
+		t.Errorf("unexpected branches then=%+v else=%+v", cond.Then, cond.Else)
+	}
+	if unsupported := findModel(models, "Unsupported"); len(unsupported.Conditions) != 0 {
+		t.Errorf("expected no condition on Unsupported, got %+v", unsupported.Conditions)
+	}
+}
+
+func TestMapAPIFromPaths_Defs(t *testing.T) {
+	doc := loadDoc(t, `
+openapi: 3.1.0
+info: {title: t, version: "1"}
+paths:
+  /things:
+    post:
+      operationId: addThing
+      tags: [thing]
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/Thing/$defs/Inner'}
+      responses:
+        "204": {description: ok}
+components:
+  schemas:
+    Thing:
+      type: object
+      $defs:
+        Inner:
+          type: object
+          properties:
+            v: {type: string}
+      properties:
+        inner: {$ref: '#/components/schemas/Thing/$defs/Inner'}
+`)
+	thing := findModel(MapModelsFromSchemas(doc), "Thing")
+	if thing == nil {
+		t.Fatalf("expected model Thing to be generated")
+	}
+	assertField(t, thing.Fields, "Inner", "ThingInner", "inner")
+
+	models := MapModelsFromPaths(doc)
+	api := findAPIByOperationID(MapAPIFromPaths(doc), "thing", "AddThing")
+	if api == nil || api.RequestBody.ModelName != "AddThingRequest" {
+		t.Fatalf("expected request body AddThingRequest, got %+v", api)
+	}
+	if request := findModel(models, "AddThingRequest"); request == nil {
+		t.Errorf("expected model AddThingRequest to be generated")
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	doc := loadDoc(t, `
+openapi: 3.1.0
+info: {title: t, version: "1"}
+paths:
+  /things:
+    get:
+      operationId: listThings
+      parameters:
+        - {name: filter, in: query, schema: {type: [string, boolean]}}
+        - {name: ids, in: query, explode: false, schema: {type: array, items: {type: integer}}}
+        - {name: box, in: query, style: deepObject, schema: {type: object}}
+        - {name: raw, in: query, content: {application/json: {schema: {type: object}}}}
+      responses:
+        "204": {description: ok}
+components:
+  schemas:
+    Thing:
+      type: object
+      properties:
+        name: {type: [string, "null"]}
+        pair: {type: array, prefixItems: [{type: string}, {type: integer}]}
+        data: {type: string, contentEncoding: base32}
+        extra: {type: object, patternProperties: {"^x": {type: string}}}
+        quote: {type: string, enum: [plain, 'say "hi"']}
+      if: {properties: {name: {minLength: 1}}}
+      then: {required: [pair]}
+`)
+	want := []string{
+		"#/components/schemas/Thing/properties/data: contentEncoding base32 is kept as string",
+		"#/components/schemas/Thing/properties/extra: patternProperties is not supported",
+		"#/components/schemas/Thing/properties/pair: prefixItems of different types are mapped to []interface{}",
+		"#/components/schemas/Thing/properties/quote: enum value \"say \\\"hi\\\"\" cannot be written in a validate tag, only Validate checks the enum",
+		"#/components/schemas/Thing: if/then/else is only checked for const properties and required lists",
+		"GET /things parameter box: object parameters are bound from a JSON encoded value",
+		"GET /things parameter filter: type [string boolean] is mapped to interface{}",
+		"GET /things parameter ids: arrays are bound exploded, as repeated parameters",
+		"GET /things parameter raw: content is bound as a plain string",
+	}
+	if got := Diagnose(doc); !slices.Equal(got, want) {
+		t.Errorf("expected diagnostics\n%q\ngot\n%q", want, got)
+	}
+}
@@ -2,122 +2,577 @@ package mapper
 
 import (
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/iancoleman/strcase"
+	"gopenapi/internal/config"
 	"gopenapi/internal/templates"
 	"gopenapi/internal/utils"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 func MapModelsFromSchemas(doc *openapi3.T) []templates.Model {
 	var models []templates.Model
-	for name, schema := range doc.Components.Schemas {
-		if schema.Value == nil {
+	if doc.Components == nil {
+		return models
+	}
+	for _, name := range slices.Sorted(maps.Keys(doc.Components.Schemas)) {
+		schema := doc.Components.Schemas[name]
+		if schema.Value == nil || extBool(schema.Value.Extensions, extSkip) ||
+			extString(schema.Value.Extensions, extGoType) != "" {
 			continue
 		}
-		parseSchema(name, schema, &models)
+		// The component is generated under its own name, even when it is
+		// itself a reference to another component.
+		parseSchema(name, &openapi3.SchemaRef{Value: schema.Value}, &models)
 	}
 	return models
 }
 
 func parseSchema(name string, schema *openapi3.SchemaRef, models *[]templates.Model) string {
+	return parseSchemaVisiting(name, schema, models, map[*openapi3.Schema]string{})
+}
+
+// parseSchemaVisiting is parseSchema with the models currently being
+// generated keyed by their schema, so recursive and mutually recursive
+// schemas refer back to the model in progress instead of expanding forever.
+func parseSchemaVisiting(name string, schema *openapi3.SchemaRef, models *[]templates.Model, visiting map[*openapi3.Schema]string) string {
 	if schema == nil || schema.Value == nil {
 		return "interface{}"
 	}
-	if schema.Value.Type.Is(openapi3.TypeString) {
-		return "string"
+	if modelName, ok := visiting[schema.Value]; ok {
+		return modelName
+	}
+	if goType := extString(schema.Value.Extensions, extGoType); goType != "" {
+		return goType
+	}
+	if modelName := componentModel(schema); modelName != "" {
+		return modelName
+	}
+	if isType(schema.Value, openapi3.TypeString) {
+		return stringType(schema.Value)
 	}
-	if schema.Value.Type.Is(openapi3.TypeInteger) {
+	if isType(schema.Value, openapi3.TypeInteger) {
 		return "int"
 	}
-	if schema.Value.Type.Is(openapi3.TypeNumber) {
+	if isType(schema.Value, openapi3.TypeNumber) {
 		return "float64"
 	}
-	if schema.Value.Type.Is(openapi3.TypeBoolean) {
+	if isType(schema.Value, openapi3.TypeBoolean) {
 		return "bool"
 	}
-	if schema.Value.Type.Is(openapi3.TypeArray) {
-		itemType := parseSchema(name+"Item", schema.Value.Items, models)
+	if _, ok := schema.Value.Extensions[kwPrefixItems]; ok && isType(schema.Value, openapi3.TypeArray) {
+		return tupleType(name, schema.Value, models, visiting)
+	}
+	if isType(schema.Value, openapi3.TypeArray) {
+		itemType := parseSchemaVisiting(name+"Item", schema.Value.Items, models, visiting)
 		return "[]" + itemType
 	}
-	if schema.Value.Type.Is(openapi3.TypeObject) {
+	if isType(schema.Value, openapi3.TypeObject) {
 		modelName := utils.CapitalizeFirstWord(name)
+		if goName := extString(schema.Value.Extensions, extGoName); goName != "" {
+			modelName = goName
+		}
+		visiting[schema.Value] = modelName
+		defer delete(visiting, schema.Value)
 		var fields []templates.ModelProp
-		for propName, propSchema := range schema.Value.Properties {
-			goType := parseSchema(name+utils.CapitalizeFirstWord(propName), propSchema, models)
-			fields = append(fields, templates.ModelProp{
-				GoName:      utils.CapitalizeFirstWord(propName),
+		var imports []string
+		// Properties are sorted by name so the generated code is stable.
+		for _, propName := range slices.Sorted(maps.Keys(schema.Value.Properties)) {
+			propSchema := schema.Value.Properties[propName]
+			if extBool(propSchema.Value.Extensions, extSkip) {
+				continue
+			}
+			goType := parseSchemaVisiting(name+utils.CapitalizeFirstWord(propName), propSchema, models, visiting)
+			nested := nestedKind(propSchema)
+			_, cycle := visiting[propSchema.Value]
+			if cycle || nested == "model" && componentModel(propSchema) != "" && refersBack(propSchema.Value, visiting) {
+				// A struct cannot contain itself, break the cycle with a pointer.
+				goType = "*" + goType
+				nested = "pointer"
+			}
+			// Nullable scalars are pointers so null is told apart from the
+			// zero value, their constraints apply to the value pointed to.
+			valueType := goType
+			nullable := isNullable(propSchema.Value) && isScalar(goType)
+			if nullable {
+				goType = "*" + goType
+			}
+			// Required readOnly properties are only required in responses,
+			// the server never receives them.
+			required := slices.Contains(schema.Value.Required, propName) && !propSchema.Value.ReadOnly
+			goName := utils.CapitalizeFirstWord(propName)
+			if override := extString(propSchema.Value.Extensions, extGoName); override != "" && propSchema.Ref == "" {
+				goName = override
+			}
+			field := templates.ModelProp{
+				GoName:      goName,
 				GoType:      goType,
 				JSONName:    propName,
-				Description: propSchema.Value.Description,
-			})
+				Description: fieldDescription(propSchema.Value),
+				Required:    required,
+				Nullable:    nullable,
+				Nested:      nested,
+				Validations: mapValidations(propSchema.Value, valueType, required && !nullable),
+				XMLName:     xmlName(propName, propSchema.Value),
+				ValidateTag: validateTag(propSchema.Value, valueType, required && !nullable, nested),
+				Default:     defaultLiteral(propSchema.Value, goType),
+				ReadOnly:    propSchema.Value.ReadOnly,
+				WriteOnly:   propSchema.Value.WriteOnly,
+				OmitEmpty:   extBool(propSchema.Value.Extensions, extOmitEmpty),
+				JSONIgnore:  extBool(propSchema.Value.Extensions, extJSONIgnore),
+			}
+			field.Tags = structTag(field, config.StructTags{})
+			fields = append(fields, field)
+			imports = append(imports, schemaImports(propSchema)...)
 		}
 		*models = append(*models, templates.Model{
 			Name:         modelName,
 			OriginalName: name,
 			Fields:       fields,
+			Conditions:   mapConditions(schema.Value, fields),
+			Imports:      uniqueSorted(imports),
 		})
 		return modelName
 	}
 	return "interface{}"
 }
 
+// componentModel returns the name of the model generated for the component
+// schema referenced by schema, or "" when it does not refer to a component
+// generated as a model.
+func componentModel(schema *openapi3.SchemaRef) string {
+	name, ok := strings.CutPrefix(schema.Ref, "#/components/schemas/")
+	if !ok || strings.Contains(name, "/") {
+		return ""
+	}
+	// An unresolved reference is taken to name a model.
+	if schema.Value == nil {
+		return refName(schema.Ref)
+	}
+	if extString(schema.Value.Extensions, extGoType) != "" || !isType(schema.Value, openapi3.TypeObject) {
+		return ""
+	}
+	if goName := extString(schema.Value.Extensions, extGoName); goName != "" {
+		return goName
+	}
+	return refName(schema.Ref)
+}
+
+// refersBack reports whether schema refers, directly or through other
+// schemas, to one of the models being generated.
+func refersBack(schema *openapi3.Schema, visiting map[*openapi3.Schema]string) bool {
+	seen := map[*openapi3.Schema]bool{}
+	var walk func(*openapi3.SchemaRef) bool
+	walk = func(ref *openapi3.SchemaRef) bool {
+		if ref == nil || ref.Value == nil || seen[ref.Value] {
+			return false
+		}
+		if _, ok := visiting[ref.Value]; ok {
+			return true
+		}
+		seen[ref.Value] = true
+		for _, prop := range ref.Value.Properties {
+			if walk(prop) {
+				return true
+			}
+		}
+		return walk(ref.Value.Items) || walk(ref.Value.AdditionalProperties.Schema)
+	}
+	return walk(&openapi3.SchemaRef{Value: schema})
+}
+
+// schemaType returns the Go type of a request or response body schema.
+// Component schemas are referred to by name, arrays and maps wrap their
+// element type and inline objects become models named after name.
+func schemaType(name string, schema *openapi3.SchemaRef, models *[]templates.Model) string {
+	if schema == nil {
+		return "interface{}"
+	}
+	if schema.Value != nil && extString(schema.Value.Extensions, extGoType) != "" {
+		return extString(schema.Value.Extensions, extGoType)
+	}
+	if modelName := componentModel(schema); modelName != "" {
+		return modelName
+	}
+	if schema.Value == nil {
+		return "interface{}"
+	}
+	if isType(schema.Value, openapi3.TypeArray) {
+		return "[]" + schemaType(name+"Item", schema.Value.Items, models)
+	}
+	if isType(schema.Value, openapi3.TypeObject) && len(schema.Value.Properties) == 0 &&
+		schema.Value.AdditionalProperties.Schema != nil {
+		return "map[string]" + schemaType(name+"Value", schema.Value.AdditionalProperties.Schema, models)
+	}
+	return parseSchema(name, schema, models)
+}
+
+// MapModelsFromPaths returns the models generated for inline request and
+// response schemas, named <OperationID>Request and <OperationID>Response.
+func MapModelsFromPaths(doc *openapi3.T) []templates.Model {
+	var models []templates.Model
+	mapOperations(doc, &models)
+	return models
+}
+
 func MapAPIFromPaths(doc *openapi3.T) templates.APIs {
+	var discard []templates.Model
+	return mapOperations(doc, &discard)
+}
+
+func mapOperations(doc *openapi3.T, models *[]templates.Model) templates.APIs {
 	apis := templates.APIs{}
-	for path, item := range doc.Paths.Map() {
-		for method, operation := range item.Operations() {
+	paths := doc.Paths.Map()
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		item := paths[path]
+		operations := item.Operations()
+		for _, method := range slices.Sorted(maps.Keys(operations)) {
+			operation := operations[method]
+			if extBool(operation.Extensions, extSkip) {
+				continue
+			}
 			tag := "default"
 			if len(operation.Tags) > 0 {
 				tag = strings.ToLower(operation.Tags[0])
 			}
 			var reqBody *templates.RequestBody
-			resp := mapResponses(operation.Responses)
+			operationID := utils.CapitalizeFirstWord(operation.OperationID)
+			if goName := extString(operation.Extensions, extGoName); goName != "" {
+				operationID = goName
+			}
+			responses := mapResponses(operationID, operation.Responses, models)
 			if operation.RequestBody != nil && operation.RequestBody.Value != nil {
-				reqBody = mapRequestBody(operation.RequestBody.Value)
+				reqBody = mapRequestBody(operationID, operation.RequestBody.Value, models)
 			}
 			apis[tag] = append(apis[tag], templates.API{
-				OperationID: utils.CapitalizeFirstWord(operation.OperationID),
+				OperationID: operationID,
 				Method:      strings.ToUpper(method),
 				Path:        cleanPath(path),
 				Description: operation.Description,
+				Parameters:  mapParameters(operationID, item, operation, models),
 				RequestBody: reqBody,
-				Response:    resp,
+				Response:    primaryResponse(responses),
+				Responses:   responses,
+				Security:    mapSecurity(doc, operation),
+				Imports:     operationImports(operation),
 			})
 		}
 	}
 	return apis
 }
 
-func mapRequestBody(value *openapi3.RequestBody) *templates.RequestBody {
-	var reqBody *templates.RequestBody
-	for mt, mediaType := range value.Content {
-		if mt == "application/json" && mediaType.Schema != nil && mediaType.Schema.Ref != "" {
-			parts := strings.Split(mediaType.Schema.Ref, "/")
-			schemaName := parts[len(parts)-1]
-			reqBody = &templates.RequestBody{
-				ModelName: utils.CapitalizeFirstWord(schemaName),
-			}
+// MapSecuritySchemes returns the security schemes declared in the
+// components, sorted by name.
+func MapSecuritySchemes(doc *openapi3.T) []templates.SecurityScheme {
+	var schemes []templates.SecurityScheme
+	if doc.Components == nil {
+		return schemes
+	}
+	for name, ref := range doc.Components.SecuritySchemes {
+		if ref.Value == nil {
+			continue
+		}
+		schemes = append(schemes, templates.SecurityScheme{
+			Name:        name,
+			GoName:      strcase.ToCamel(name),
+			Type:        ref.Value.Type,
+			Scheme:      strings.ToLower(ref.Value.Scheme),
+			In:          ref.Value.In,
+			ParamName:   ref.Value.Name,
+			Description: ref.Value.Description,
+		})
+	}
+	sort.Slice(schemes, func(i, j int) bool { return schemes[i].Name < schemes[j].Name })
+	return schemes
+}
+
+// mapSecurity returns the security requirements of operation, falling back
+// to the document-wide requirements when the operation declares none.
+func mapSecurity(doc *openapi3.T, operation *openapi3.Operation) []templates.SecurityRequirement {
+	requirements := doc.Security
+	if operation.Security != nil {
+		requirements = *operation.Security
+	}
+	var security []templates.SecurityRequirement
+	for _, requirement := range requirements {
+		var req templates.SecurityRequirement
+		for name, scopes := range requirement {
+			req.Schemes = append(req.Schemes, templates.SecurityScopes{Name: name, Scopes: scopes})
 		}
+		sort.Slice(req.Schemes, func(i, j int) bool { return req.Schemes[i].Name < req.Schemes[j].Name })
+		security = append(security, req)
 	}
-	return reqBody
+	return security
 }
 
-func mapResponses(resp *openapi3.Responses) *templates.Response {
+func mapRequestBody(operationID string, value *openapi3.RequestBody, models *[]templates.Model) *templates.RequestBody {
+	if len(value.Content) == 0 {
+		return nil
+	}
+	reqBody := &templates.RequestBody{
+		ContentTypes: sortedMediaTypes(value.Content),
+		Required:     value.Required,
+	}
+
+	// Structured bodies (JSON, XML, forms) bind into a single Go type, so the
+	// first schema found, preferring JSON, decides the model.
+	var structured []string
+	for _, mt := range preferJSON(reqBody.ContentTypes) {
+		media := value.Content[mt]
+		kind := mediaKind(mt)
+		if kind == "binary" || kind == "text" || media.Schema == nil {
+			continue
+		}
+		structured = append(structured, kind)
+		if reqBody.ModelName != "" || reqBody.TypeName != "" {
+			continue
+		}
+		if (kind == "form" || kind == "multipart") && media.Schema.Ref == "" && media.Schema.Value != nil &&
+			isType(media.Schema.Value, openapi3.TypeObject) {
+			reqBody.TypeName = operationID + "Form"
+			reqBody.FormFields = mapFormFields(media.Schema.Value)
+		} else {
+			reqBody.ModelName = schemaType(operationID+"Request", media.Schema, models)
+		}
+		reqBody.ContentType = mt
+	}
+	if reqBody.ModelName != "" || reqBody.TypeName != "" {
+		reqBody.Binding = "bind"
+		if len(structured) == 1 && (structured[0] == "json" || structured[0] == "xml") {
+			reqBody.Binding = structured[0]
+		}
+		reqBody.Encoding = mediaKind(reqBody.ContentType)
+		return reqBody
+	}
+
+	for _, mt := range reqBody.ContentTypes {
+		if kind := mediaKind(mt); kind == "text" || kind == "binary" {
+			reqBody.Binding = kind
+			reqBody.Encoding = kind
+			reqBody.ContentType = mt
+			return reqBody
+		}
+	}
+	return nil
+}
+
+// mediaKind groups a media type by how its payload is bound: json, xml,
+// form, multipart, text or binary.
+func mediaKind(mt string) string {
+	switch {
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		return "json"
+	case mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml"):
+		return "xml"
+	case mt == "application/x-www-form-urlencoded":
+		return "form"
+	case mt == "multipart/form-data":
+		return "multipart"
+	case strings.HasPrefix(mt, "text/"):
+		return "text"
+	default:
+		return "binary"
+	}
+}
+
+func mapFormFields(schema *openapi3.Schema) []templates.FormField {
+	var fields []templates.FormField
+	for name, prop := range schema.Properties {
+		field := templates.FormField{
+			Name:   name,
+			GoName: utils.CapitalizeFirstWord(name),
+		}
+		switch {
+		case isBinary(prop):
+			field.GoType = "*multipart.FileHeader"
+			field.IsFile = true
+		case prop.Value != nil && isType(prop.Value, openapi3.TypeArray) && isBinary(prop.Value.Items):
+			field.GoType = "[]*multipart.FileHeader"
+			field.IsFile = true
+		case prop.Value != nil && isType(prop.Value, openapi3.TypeString):
+			// Form values are not base64 decoded, so format byte stays a string.
+			field.GoType = "string"
+		default:
+			var discard []templates.Model
+			field.GoType = parseSchema(name, prop, &discard)
+		}
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+// isBinary reports whether a string schema holds raw bytes: format binary,
+// or a binary contentMediaType without a contentEncoding.
+func isBinary(schema *openapi3.SchemaRef) bool {
+	if schema == nil || schema.Value == nil || !isType(schema.Value, openapi3.TypeString) {
+		return false
+	}
+	mediaType := extString(schema.Value.Extensions, kwContentMediaType)
+	return schema.Value.Format == "binary" || mediaType != "" && mediaKind(mediaType) == "binary" &&
+		extString(schema.Value.Extensions, kwContentEncoding) == ""
+}
+
+func sortedMediaTypes(content openapi3.Content) []string {
+	types := make([]string, 0, len(content))
+	for mt := range content {
+		types = append(types, mt)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// preferJSON moves application/json to the front of types.
+func preferJSON(types []string) []string {
+	ordered := make([]string, 0, len(types))
+	for _, mt := range types {
+		if mt == "application/json" {
+			ordered = append(ordered, mt)
+		}
+	}
+	for _, mt := range types {
+		if mt != "application/json" {
+			ordered = append(ordered, mt)
+		}
+	}
+	return ordered
+}
+
+func mapResponses(operationID string, resp *openapi3.Responses, models *[]templates.Model) []templates.Response {
+	var responses []templates.Response
+	if resp == nil {
+		return responses
+	}
+	primary := primaryStatus(resp)
 	for status, response := range resp.Map() {
-		if status[0] == '2' && response.Value != nil {
-			for mt, media := range response.Value.Content {
-				if mt == "application/json" && media.Schema != nil && media.Schema.Ref != "" {
-					parts := strings.Split(media.Schema.Ref, "/")
-					schemaName := parts[len(parts)-1]
-					return &templates.Response{
-						Status:    status,
-						ModelName: utils.CapitalizeFirstWord(schemaName),
-					}
-				}
+		if response.Value == nil {
+			continue
+		}
+		bodyName := operationID + responseSuffix(status) + "ResponseBody"
+		if status == primary {
+			bodyName = operationID + "Response"
+		}
+		r := templates.Response{
+			Status:   status,
+			TypeName: operationID + responseSuffix(status) + "Response",
+		}
+		if code, err := strconv.Atoi(status); err == nil {
+			r.StatusCode = code
+		}
+		if response.Value.Description != nil {
+			r.Description = *response.Value.Description
+		}
+		if mt, media := pickMediaType(response.Value.Content); media != nil {
+			r.ContentType = mt
+			r.Binding = mediaKind(mt)
+			switch {
+			case r.Binding == "binary":
+				// Binary payloads are written as is, whatever their schema.
+				r.ModelName = "[]byte"
+			case media.Schema != nil:
+				r.ModelName = schemaType(bodyName, media.Schema, models)
 			}
 		}
+		for name, header := range response.Value.Headers {
+			if header.Value == nil {
+				continue
+			}
+			r.Headers = append(r.Headers, templates.ResponseHeader{
+				Name:        name,
+				GoName:      strcase.ToCamel(name),
+				GoType:      headerType(operationID+strcase.ToCamel(name)+"Header", header.Value.Schema, models),
+				Description: header.Value.Description,
+			})
+		}
+		sort.Slice(r.Headers, func(i, j int) bool { return r.Headers[i].Name < r.Headers[j].Name })
+		responses = append(responses, r)
+	}
+	sort.Slice(responses, func(i, j int) bool {
+		return statusOrder(responses[i].Status) < statusOrder(responses[j].Status)
+	})
+	return responses
+}
+
+// headerType returns the Go type of a response header. Headers are written
+// with fmt.Sprint, so objects and arrays, which have no single header
+// serialization, are left to the handler as a string.
+func headerType(name string, schema *openapi3.SchemaRef, models *[]templates.Model) string {
+	if schema == nil || schema.Value == nil ||
+		isType(schema.Value, openapi3.TypeObject) || isType(schema.Value, openapi3.TypeArray) {
+		return "string"
+	}
+	return schemaType(name, schema, models)
+}
+
+// primaryStatus returns the status of the lowest declared 2xx response,
+// falling back to the first declared one. Its inline body is named
+// <OperationID>Response.
+func primaryStatus(resp *openapi3.Responses) string {
+	var statuses []string
+	for status, response := range resp.Map() {
+		if response.Value != nil {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statusOrder(statuses[i]) < statusOrder(statuses[j]) })
+	for _, status := range statuses {
+		if status[0] == '2' {
+			return status
+		}
+	}
+	if len(statuses) > 0 {
+		return statuses[0]
+	}
+	return ""
+}
+
+// primaryResponse returns the lowest declared 2xx response, falling back to
+// the first declared one.
+func primaryResponse(responses []templates.Response) *templates.Response {
+	for i := range responses {
+		if responses[i].Status[0] == '2' {
+			return &responses[i]
+		}
+	}
+	if len(responses) > 0 {
+		return &responses[0]
 	}
 	return nil
 }
 
+func responseSuffix(status string) string {
+	if status == "default" {
+		return "Default"
+	}
+	return strings.ToUpper(status)
+}
+
+// statusOrder sorts concrete statuses first, then ranges like 4XX, then default.
+func statusOrder(status string) string {
+	if status == "default" {
+		return "9" + status
+	}
+	return strings.ToUpper(status)
+}
+
+// pickMediaType prefers application/json and otherwise returns the first
+// media type in lexical order.
+func pickMediaType(content openapi3.Content) (string, *openapi3.MediaType) {
+	types := preferJSON(sortedMediaTypes(content))
+	if len(types) == 0 {
+		return "", nil
+	}
+	return types[0], content[types[0]]
+}
+
+func refName(ref string) string {
+	parts := strings.Split(ref, "/")
+	return utils.CapitalizeFirstWord(parts[len(parts)-1])
+}
+
 func cleanPath(path string) string {
 	path = strings.ReplaceAll(path, "{", ":")
 	path = strings.ReplaceAll(path, "}", "")
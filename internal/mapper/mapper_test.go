@@ -71,6 +71,17 @@ func TestMapModelsFromSchemas_BasicAndNested(t *testing.T) {
 	assertField(t, personProfile.Fields, "Age", "int", "age")
 }
 
+func TestMapModelsFromSchemas_NoComponents(t *testing.T) {
+	doc := loadDoc(t, `
+openapi: 3.0.0
+info: {title: t, version: "1"}
+paths: {}
+`)
+	if models := MapModelsFromSchemas(doc); len(models) != 0 {
+		t.Errorf("expected no models, got %+v", models)
+	}
+}
+
 func TestMapAPIFromPaths_WithGetAndPost(t *testing.T) {
 	doc := &openapi3.T{
 		Components: &openapi3.Components{},
@@ -185,6 +196,341 @@ func TestMapAPIFromPaths_WithGetAndPost(t *testing.T) {
 	}
 }
 
+func TestMapAPIFromPaths_AllResponses(t *testing.T) {
+	doc := &openapi3.T{Components: &openapi3.Components{}}
+	doc.Paths = openapi3.NewPaths()
+
+	op := &openapi3.Operation{
+		OperationID: "loginUser",
+		Tags:        []string{"user"},
+		Responses:   openapi3.NewResponses(),
+	}
+	op.Responses.Set("200", &openapi3.ResponseRef{
+		Value: &openapi3.Response{
+			Description: openapi3.Ptr("successful operation"),
+			Headers: openapi3.Headers{
+				"X-Rate-Limit": {Value: &openapi3.Header{Parameter: openapi3.Parameter{
+					Schema: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeInteger}}},
+				}}},
+			},
+			Content: openapi3.Content{
+				"application/json": &openapi3.MediaType{
+					Schema: &openapi3.SchemaRef{Ref: "#/components/schemas/Session"},
+				},
+			},
+		},
+	})
+	op.Responses.Set("400", &openapi3.ResponseRef{Value: &openapi3.Response{}})
+	op.Responses.Set("default", &openapi3.ResponseRef{
+		Value: &openapi3.Response{
+			Content: openapi3.Content{
+				"application/json": &openapi3.MediaType{
+					Schema: &openapi3.SchemaRef{Ref: "#/components/schemas/Error"},
+				},
+			},
+		},
+	})
+	doc.Paths.Set("/user/login", &openapi3.PathItem{Get: op})
+
+	api := findAPIByOperationID(MapAPIFromPaths(doc), "user", "LoginUser")
+	if api == nil {
+		t.Fatalf("expected operation LoginUser")
+	}
+	if len(api.Responses) != 3 {
+		t.Fatalf("expected 3 responses, got %d", len(api.Responses))
+	}
+	wantStatus := []string{"200", "400", "default"}
+	wantType := []string{"LoginUser200Response", "LoginUser400Response", "LoginUserDefaultResponse"}
+	for i, resp := range api.Responses {
+		if resp.Status != wantStatus[i] {
+			t.Errorf("response %d: expected status %q, got %q", i, wantStatus[i], resp.Status)
+		}
+		if resp.TypeName != wantType[i] {
+			t.Errorf("response %d: expected type %q, got %q", i, wantType[i], resp.TypeName)
+		}
+	}
+
+	ok := api.Responses[0]
+	if ok.StatusCode != 200 || ok.ModelName != "Session" || ok.ContentType != "application/json" {
+		t.Errorf("unexpected 200 response: %+v", ok)
+	}
+	if ok.Description != "successful operation" {
+		t.Errorf("expected 200 description to be kept, got %q", ok.Description)
+	}
+	if len(ok.Headers) != 1 || ok.Headers[0].GoName != "XRateLimit" || ok.Headers[0].GoType != "int" {
+		t.Errorf("unexpected 200 headers: %+v", ok.Headers)
+	}
+
+	def := api.Responses[2]
+	if def.StatusCode != 0 || def.ModelName != "Error" {
+		t.Errorf("unexpected default response: %+v", def)
+	}
+	if api.Response == nil || api.Response.Status != "200" {
+		t.Errorf("expected primary response to be 200, got %+v", api.Response)
+	}
+}
+
+func TestMapRequestBody_ContentTypes(t *testing.T) {
+	ref := &openapi3.SchemaRef{Ref: "#/components/schemas/Pet"}
+	binary := &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}, Format: "binary"}}
+
+	tests := []struct {
+		name      string
+		content   openapi3.Content
+		binding   string
+		modelName string
+		typeName  string
+	}{
+		{
+			name:      "json only",
+			content:   openapi3.Content{"application/json": {Schema: ref}},
+			binding:   "json",
+			modelName: "Pet",
+		},
+		{
+			name: "json, xml and form",
+			content: openapi3.Content{
+				"application/json":                  {Schema: ref},
+				"application/xml":                   {Schema: ref},
+				"application/x-www-form-urlencoded": {Schema: ref},
+			},
+			binding:   "bind",
+			modelName: "Pet",
+		},
+		{
+			name: "multipart inline",
+			content: openapi3.Content{"multipart/form-data": {Schema: &openapi3.SchemaRef{Value: &openapi3.Schema{
+				Type:       &openapi3.Types{openapi3.TypeObject},
+				Properties: openapi3.Schemas{"file": binary},
+			}}}},
+			binding:  "bind",
+			typeName: "UploadFileForm",
+		},
+		{
+			name:    "octet-stream",
+			content: openapi3.Content{"application/octet-stream": {Schema: binary}},
+			binding: "binary",
+		},
+		{
+			name:    "text",
+			content: openapi3.Content{"text/plain": {Schema: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}}}}},
+			binding: "text",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var models []templates.Model
+			body := mapRequestBody("UploadFile", &openapi3.RequestBody{Content: tt.content}, &models)
+			if body == nil {
+				t.Fatalf("expected request body to be mapped")
+			}
+			if body.Binding != tt.binding {
+				t.Errorf("expected binding %q, got %q", tt.binding, body.Binding)
+			}
+			if body.ModelName != tt.modelName {
+				t.Errorf("expected model %q, got %q", tt.modelName, body.ModelName)
+			}
+			if body.TypeName != tt.typeName {
+				t.Errorf("expected type %q, got %q", tt.typeName, body.TypeName)
+			}
+		})
+	}
+}
+
+func TestMapResponses_ContentTypesAndHeaders(t *testing.T) {
+	str := &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}}}
+	object := &openapi3.SchemaRef{Value: &openapi3.Schema{
+		Type:       &openapi3.Types{openapi3.TypeObject},
+		Properties: openapi3.Schemas{"id": str},
+	}}
+	responses := openapi3.NewResponsesWithCapacity(3)
+	responses.Set("200", &openapi3.ResponseRef{Value: &openapi3.Response{
+		Headers: openapi3.Headers{
+			"X-Trace": {Value: &openapi3.Header{Parameter: openapi3.Parameter{Schema: object}}},
+			"X-Id":    {Value: &openapi3.Header{Parameter: openapi3.Parameter{Schema: str}}},
+		},
+		Content: openapi3.Content{"text/plain": {Schema: str}},
+	}})
+	responses.Set("201", &openapi3.ResponseRef{Value: &openapi3.Response{
+		Content: openapi3.Content{"application/xml": {Schema: &openapi3.SchemaRef{Ref: "#/components/schemas/Pet"}}},
+	}})
+	responses.Set("202", &openapi3.ResponseRef{Value: &openapi3.Response{
+		Content: openapi3.Content{"image/png": {Schema: str}},
+	}})
+
+	var models []templates.Model
+	got := mapResponses("GetPet", responses, &models)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 responses, got %d", len(got))
+	}
+	want := []struct{ binding, modelName string }{
+		{"text", "string"},
+		{"xml", "Pet"},
+		{"binary", "[]byte"},
+	}
+	for i, w := range want {
+		if got[i].Binding != w.binding || got[i].ModelName != w.modelName {
+			t.Errorf("response %s: expected %s %s, got %s %s", got[i].Status, w.binding, w.modelName, got[i].Binding, got[i].ModelName)
+		}
+	}
+	headers := got[0].Headers
+	if len(headers) != 2 || headers[0].GoType != "string" || headers[1].GoType != "string" {
+		t.Errorf("expected object header to fall back to string, got %+v", headers)
+	}
+	if len(models) != 0 {
+		t.Errorf("expected no models, got %+v", models)
+	}
+}
+
+func TestMapFormFields_Files(t *testing.T) {
+	binary := &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}, Format: "binary"}}
+	schema := &openapi3.Schema{
+		Type: &openapi3.Types{openapi3.TypeObject},
+		Properties: openapi3.Schemas{
+			"file":  binary,
+			"files": {Value: &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeArray}, Items: binary}},
+			"note":  {Value: &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}}},
+			"hash":  {Value: &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}, Format: "byte"}},
+			"image": {Value: &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString},
+				Extensions: map[string]any{"contentMediaType": "image/png"}}},
+			"notes": {Value: &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString},
+				Extensions: map[string]any{"contentMediaType": "text/plain"}}},
+			"photo": {Value: &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString},
+				Extensions: map[string]any{"contentMediaType": "image/png", "contentEncoding": "base64"}}},
+		},
+	}
+	fields := mapFormFields(schema)
+	want := map[string]string{
+		"file":  "*multipart.FileHeader",
+		"files": "[]*multipart.FileHeader",
+		"note":  "string",
+		"hash":  "string",
+		"image": "*multipart.FileHeader",
+		"notes": "string",
+		"photo": "string",
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("expected %d fields, got %d", len(want), len(fields))
+	}
+	for _, f := range fields {
+		if f.GoType != want[f.Name] {
+			t.Errorf("field %s: expected GoType %q, got %q", f.Name, want[f.Name], f.GoType)
+		}
+		if f.IsFile != (f.Name == "file" || f.Name == "files" || f.Name == "image") {
+			t.Errorf("field %s: unexpected IsFile %v", f.Name, f.IsFile)
+		}
+	}
+}
+
+func TestMapModelsFromPaths_InlineSchemas(t *testing.T) {
+	doc := &openapi3.T{Components: &openapi3.Components{}}
+	doc.Paths = openapi3.NewPaths()
+
+	inline := &openapi3.Schema{
+		Type: &openapi3.Types{openapi3.TypeObject},
+		Properties: openapi3.Schemas{
+			"name": {Value: &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}}},
+		},
+	}
+	createOp := &openapi3.Operation{
+		OperationID: "createPet",
+		Tags:        []string{"pet"},
+		RequestBody: &openapi3.RequestBodyRef{Value: &openapi3.RequestBody{
+			Content: openapi3.Content{"application/json": {Schema: &openapi3.SchemaRef{Value: inline}}},
+		}},
+		Responses: openapi3.NewResponses(),
+	}
+	createOp.Responses.Set("201", &openapi3.ResponseRef{Value: &openapi3.Response{
+		Content: openapi3.Content{"application/json": {Schema: &openapi3.SchemaRef{Value: inline}}},
+	}})
+	findOp := &openapi3.Operation{
+		OperationID: "findPets",
+		Tags:        []string{"pet"},
+		Responses:   openapi3.NewResponses(),
+	}
+	findOp.Responses.Set("200", &openapi3.ResponseRef{Value: &openapi3.Response{
+		Content: openapi3.Content{"application/json": {Schema: &openapi3.SchemaRef{Value: &openapi3.Schema{
+			Type:  &openapi3.Types{openapi3.TypeArray},
+			Items: &openapi3.SchemaRef{Ref: "#/components/schemas/Pet"},
+		}}}},
+	}})
+	doc.Paths.Set("/pets", &openapi3.PathItem{Post: createOp, Get: findOp})
+
+	models := MapModelsFromPaths(doc)
+	if len(models) != 2 {
+		t.Fatalf("expected 2 inline models, got %d", len(models))
+	}
+	for _, name := range []string{"CreatePetRequest", "CreatePetResponse"} {
+		m := findModel(models, name)
+		if m == nil {
+			t.Fatalf("expected inline model %s", name)
+		}
+		assertField(t, m.Fields, "Name", "string", "name")
+	}
+
+	apis := MapAPIFromPaths(doc)
+	create := findAPIByOperationID(apis, "pet", "CreatePet")
+	if create == nil || create.RequestBody == nil || create.RequestBody.ModelName != "CreatePetRequest" {
+		t.Fatalf("expected CreatePet request body to use CreatePetRequest, got %+v", create)
+	}
+	if create.Response == nil || create.Response.ModelName != "CreatePetResponse" {
+		t.Fatalf("expected CreatePet response to use CreatePetResponse, got %+v", create.Response)
+	}
+	find := findAPIByOperationID(apis, "pet", "FindPets")
+	if find == nil || find.Response == nil || find.Response.ModelName != "[]Pet" {
+		t.Fatalf("expected FindPets response to be []Pet, got %+v", find)
+	}
+}
+
+func TestMapSecurity(t *testing.T) {
+	doc := &openapi3.T{
+		Components: &openapi3.Components{
+			SecuritySchemes: openapi3.SecuritySchemes{
+				"petstore_auth": {Value: &openapi3.SecurityScheme{Type: "oauth2"}},
+				"api_key":       {Value: &openapi3.SecurityScheme{Type: "apiKey", In: "header", Name: "api_key"}},
+			},
+		},
+		Security: openapi3.SecurityRequirements{{"api_key": {}}},
+	}
+	doc.Paths = openapi3.NewPaths()
+	doc.Paths.Set("/pet", &openapi3.PathItem{
+		Get: &openapi3.Operation{OperationID: "listPets", Tags: []string{"pet"}},
+		Post: &openapi3.Operation{
+			OperationID: "addPet",
+			Tags:        []string{"pet"},
+			Security:    &openapi3.SecurityRequirements{{"petstore_auth": {"write:pets"}}},
+		},
+		Delete: &openapi3.Operation{
+			OperationID: "deletePets",
+			Tags:        []string{"pet"},
+			Security:    &openapi3.SecurityRequirements{},
+		},
+	})
+
+	schemes := MapSecuritySchemes(doc)
+	if len(schemes) != 2 || schemes[0].Name != "api_key" || schemes[1].Name != "petstore_auth" {
+		t.Fatalf("expected schemes sorted by name, got %+v", schemes)
+	}
+	if schemes[0].GoName != "ApiKey" || schemes[0].In != "header" || schemes[0].ParamName != "api_key" {
+		t.Errorf("unexpected api_key scheme: %+v", schemes[0])
+	}
+
+	apis := MapAPIFromPaths(doc)
+	list := findAPIByOperationID(apis, "pet", "ListPets")
+	if len(list.Security) != 1 || list.Security[0].Schemes[0].Name != "api_key" {
+		t.Errorf("expected ListPets to inherit document security, got %+v", list.Security)
+	}
+	add := findAPIByOperationID(apis, "pet", "AddPet")
+	if len(add.Security) != 1 || add.Security[0].Schemes[0].Name != "petstore_auth" ||
+		len(add.Security[0].Schemes[0].Scopes) != 1 || add.Security[0].Schemes[0].Scopes[0] != "write:pets" {
+		t.Errorf("expected AddPet to require petstore_auth write:pets, got %+v", add.Security)
+	}
+	del := findAPIByOperationID(apis, "pet", "DeletePets")
+	if len(del.Security) != 0 {
+		t.Errorf("expected DeletePets to opt out of security, got %+v", del.Security)
+	}
+}
+
 func TestCleanPath(t *testing.T) {
 	in := "/pets/{id}/owners/{ownerId}"
 	want := "/pets/:id/owners/:ownerId"
@@ -0,0 +1,106 @@
+package mapper
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/iancoleman/strcase"
+	"gopenapi/internal/templates"
+	"sort"
+	"strings"
+)
+
+var parameterOrder = map[string]int{
+	openapi3.ParameterInPath:   0,
+	openapi3.ParameterInQuery:  1,
+	openapi3.ParameterInHeader: 2,
+	openapi3.ParameterInCookie: 3,
+}
+
+// parameterTags are the struct tags binding parameters, by location.
+var parameterTags = map[string]string{
+	openapi3.ParameterInPath:   "uri",
+	openapi3.ParameterInQuery:  "form",
+	openapi3.ParameterInHeader: "header",
+	openapi3.ParameterInCookie: "cookie",
+}
+
+// mapParameters returns the path, query, header and cookie parameters of an
+// operation, including those declared on its path item. Inline object
+// schemas become models named <OperationID><Name>Param.
+func mapParameters(operationID string, item *openapi3.PathItem, operation *openapi3.Operation, models *[]templates.Model) []templates.Parameter {
+	byKey := map[string]*openapi3.Parameter{}
+	for _, params := range []openapi3.Parameters{item.Parameters, operation.Parameters} {
+		for _, ref := range params {
+			if ref == nil || ref.Value == nil {
+				continue
+			}
+			byKey[ref.Value.In+":"+ref.Value.Name] = ref.Value
+		}
+	}
+
+	var parameters []templates.Parameter
+	for _, p := range byKey {
+		tag := parameterTags[p.In]
+		if tag == "" {
+			continue
+		}
+		param := templates.Parameter{
+			Name:        p.Name,
+			GoName:      strcase.ToCamel(p.Name),
+			GoType:      "string",
+			In:          p.In,
+			Required:    p.Required,
+			Description: p.Description,
+		}
+		if p.Schema != nil && p.Schema.Value != nil {
+			param.GoType = schemaType(operationID+strcase.ToCamel(p.Name)+"Param", p.Schema, models)
+			if goName := extString(p.Extensions, extGoName); goName != "" {
+				param.GoName = goName
+			}
+			param.Default = formDefault(p.Schema.Value.Default)
+		}
+		param.Tag = parameterTag(tag, p.Name, param.Default)
+		parameters = append(parameters, param)
+	}
+	sort.Slice(parameters, func(i, j int) bool {
+		if parameters[i].In != parameters[j].In {
+			return parameterOrder[parameters[i].In] < parameterOrder[parameters[j].In]
+		}
+		return parameters[i].Name < parameters[j].Name
+	})
+	return parameters
+}
+
+// parameter reports the parameters gin does not bind as the spec describes
+// them.
+func (d *diagnoser) parameter(at string, p *openapi3.Parameter) {
+	at += " parameter " + p.Name
+	switch {
+	case p.Schema == nil:
+		d.report(at, "content is bound as a plain string")
+	case p.Schema.Value == nil:
+	case isType(p.Schema.Value, openapi3.TypeObject):
+		d.report(at, "object parameters are bound from a JSON encoded value")
+	case p.Style != "" && p.Style != openapi3.SerializationForm && p.Style != openapi3.SerializationSimple:
+		d.report(at, "style %s is not supported", p.Style)
+	case p.In == openapi3.ParameterInQuery && p.Explode != nil && !*p.Explode && isType(p.Schema.Value, openapi3.TypeArray):
+		d.report(at, "arrays are bound exploded, as repeated parameters")
+	}
+}
+
+// parameterTag binds a parameter with one of the uri, form, header or cookie
+// tags and excludes it from the others, as gin falls back to the field name
+// for fields without a tag.
+func parameterTag(tag, name, def string) string {
+	var parts []string
+	for _, t := range []string{"uri", "form", "header", "cookie"} {
+		value := "-"
+		if t == tag {
+			value = name
+			if def != "" && t != "uri" {
+				value += ",default=" + def
+			}
+		}
+		parts = append(parts, t+`:"`+value+`"`)
+	}
+	return strings.Join(parts, " ")
+}
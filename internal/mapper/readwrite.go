@@ -0,0 +1,176 @@
+package mapper
+
+import (
+	"gopenapi/internal/templates"
+	"path"
+	"strings"
+)
+
+// ApplyReadWriteOnly makes the models respect readOnly and writeOnly
+// properties according to mode:
+//
+//   - marshal (default) leaves writeOnly fields out of the responses and
+//     clears readOnly fields in the request bodies, the models themselves
+//     encode and decode every field.
+//   - variants adds <Name>Request models without the readOnly fields and
+//     <Name>Response models without the writeOnly fields, and uses them for
+//     the request and response bodies of apis.
+//   - ignore leaves the models untouched.
+func ApplyReadWriteOnly(models []templates.Model, apis templates.APIs, mode string) []templates.Model {
+	switch mode {
+	case "ignore":
+		return models
+	case "variants":
+		requests := variantNames(models, "Request", func(f templates.ModelProp) bool { return f.ReadOnly })
+		responses := variantNames(models, "Response", func(f templates.ModelProp) bool { return f.WriteOnly })
+		models = append(models, modelVariants(models, requests, func(f templates.ModelProp) bool { return f.ReadOnly })...)
+		models = append(models, modelVariants(models, responses, func(f templates.ModelProp) bool { return f.WriteOnly })...)
+		for _, group := range apis {
+			for i := range group {
+				if body := group[i].RequestBody; body != nil {
+					body.ModelName = variantType(body.ModelName, requests)
+				}
+				for j := range group[i].Responses {
+					group[i].Responses[j].ModelName = variantType(group[i].Responses[j].ModelName, responses)
+				}
+			}
+		}
+		return models
+	default:
+		for i := range models {
+			for _, field := range models[i].Fields {
+				if field.JSONIgnore {
+					continue
+				}
+				models[i].OmitWriteOnly = models[i].OmitWriteOnly || field.WriteOnly
+				models[i].IgnoreReadOnly = models[i].IgnoreReadOnly || field.ReadOnly
+			}
+		}
+		// Only the bodies holding such fields are touched, decoding stored
+		// JSON or a response still fills readOnly fields.
+		writeOnly := holding(models, func(f templates.ModelProp) bool { return f.WriteOnly && !f.JSONIgnore })
+		readOnly := holding(models, func(f templates.ModelProp) bool { return f.ReadOnly && !f.JSONIgnore })
+		for _, group := range apis {
+			for i := range group {
+				if body := group[i].RequestBody; body != nil {
+					body.ClearReadOnly = structured(body.Binding) && readOnly[baseType(body.ModelName)]
+				}
+				for j := range group[i].Responses {
+					resp := &group[i].Responses[j]
+					resp.OmitWriteOnly = structured(resp.Binding) && writeOnly[baseType(resp.ModelName)]
+				}
+			}
+		}
+		return models
+	}
+}
+
+// structured reports whether a binding encodes the fields of models, unlike
+// text and binary bodies.
+func structured(binding string) bool {
+	return binding != "text" && binding != "binary"
+}
+
+// holding returns the names of the models with a field matching has,
+// directly or in the models they refer to.
+func holding(models []templates.Model, has func(templates.ModelProp) bool) map[string]bool {
+	names := map[string]bool{}
+	for changed := true; changed; {
+		changed = false
+		for _, model := range models {
+			if names[model.Name] {
+				continue
+			}
+			for _, field := range model.Fields {
+				if has(field) || names[baseType(field.GoType)] {
+					names[model.Name] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return names
+}
+
+// variantNames maps the models that need a variant to its name: those with
+// dropped fields and those referring to a model that needs a variant.
+// Variants whose name is taken by another model are not generated.
+func variantNames(models []templates.Model, suffix string, drop func(templates.ModelProp) bool) map[string]string {
+	taken := map[string]bool{}
+	for _, model := range models {
+		taken[model.Name] = true
+	}
+	names := map[string]string{}
+	for changed := true; changed; {
+		changed = false
+		for _, model := range models {
+			if _, ok := names[model.Name]; ok || taken[model.Name+suffix] {
+				continue
+			}
+			for _, field := range model.Fields {
+				if drop(field) || names[baseType(field.GoType)] != "" {
+					names[model.Name] = model.Name + suffix
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return names
+}
+
+// modelVariants copies the models listed in names without the dropped
+// fields, referring to the variants of other models.
+func modelVariants(models []templates.Model, names map[string]string, drop func(templates.ModelProp) bool) []templates.Model {
+	var variants []templates.Model
+	for _, model := range models {
+		name, ok := names[model.Name]
+		if !ok {
+			continue
+		}
+		variant := model
+		variant.Name = name
+		variant.Fields = nil
+		for _, field := range model.Fields {
+			if drop(field) {
+				continue
+			}
+			field.GoType = variantType(field.GoType, names)
+			variant.Fields = append(variant.Fields, field)
+		}
+		variant.Imports = usedImports(model.Imports, variant.Fields)
+		variants = append(variants, variant)
+	}
+	return variants
+}
+
+// variantType replaces the named type in a Go type expression by its variant.
+func variantType(goType string, names map[string]string) string {
+	if name, ok := names[baseType(goType)]; ok {
+		return goType[:len(goType)-len(baseType(goType))] + name
+	}
+	return goType
+}
+
+func baseType(goType string) string {
+	return goType[strings.LastIndexAny(goType, "]*")+1:]
+}
+
+// usedImports drops the imports no longer referred to by fields.
+func usedImports(imports []string, fields []templates.ModelProp) []string {
+	var used []string
+	for _, imp := range imports {
+		pkg := path.Base(imp)
+		if alias, _, ok := strings.Cut(imp, " "); ok {
+			pkg = alias
+		}
+		for _, field := range fields {
+			if strings.Contains(field.GoType, pkg+".") {
+				used = append(used, imp)
+				break
+			}
+		}
+	}
+	return used
+}
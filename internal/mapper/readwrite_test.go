@@ -0,0 +1,155 @@
+package mapper
+
+import (
+	"slices"
+	"testing"
+
+	"gopenapi/internal/templates"
+)
+
+const readWriteSpec = `
+openapi: 3.0.0
+info: {title: t, version: "1"}
+paths:
+  /users:
+    post:
+      operationId: addUser
+      tags: [user]
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/User'}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: array
+                items: {$ref: '#/components/schemas/Team'}
+  /users/{id}:
+    get:
+      operationId: getUser
+      tags: [user]
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: integer}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/xml:
+              schema: {$ref: '#/components/schemas/User'}
+components:
+  schemas:
+    User:
+      type: object
+      required: [id, password]
+      properties:
+        id: {type: integer, readOnly: true}
+        name: {type: string}
+        password: {type: string, writeOnly: true}
+    Team:
+      type: object
+      properties:
+        members:
+          type: array
+          items: {$ref: '#/components/schemas/User'}
+    Tag:
+      type: object
+      properties:
+        name: {type: string}
+`
+
+func TestApplyReadWriteOnly_Marshal(t *testing.T) {
+	doc := loadDoc(t, readWriteSpec)
+	apis := MapAPIFromPaths(doc)
+	models := ApplyReadWriteOnly(MapModelsFromSchemas(doc), apis, "")
+	user := findModel(models, "User")
+	if user == nil {
+		t.Fatalf("expected model User to be generated")
+	}
+	if !user.OmitWriteOnly || !user.IgnoreReadOnly || slices.Contains(user.Imports, "encoding/json") {
+		t.Errorf("expected User to list its readOnly and writeOnly fields without JSON methods, got %+v", user)
+	}
+	assertValidations(t, user.Fields, "Id", nil)
+	if tag := findModel(models, "Tag"); tag.OmitWriteOnly || tag.IgnoreReadOnly {
+		t.Errorf("expected Tag to be left untouched, got %+v", tag)
+	}
+	if team := findModel(models, "Team"); team.OmitWriteOnly || team.Imports != nil {
+		t.Errorf("expected Team to be left untouched, got %+v", team)
+	}
+
+	// Only the response holding Team, and through it User, drops writeOnly
+	// fields, and only the request body clears readOnly ones.
+	api := findAPIByOperationID(apis, "user", "AddUser")
+	if api == nil {
+		t.Fatalf("expected API AddUser")
+	}
+	if api.RequestBody.ModelName != "User" || !api.RequestBody.ClearReadOnly {
+		t.Errorf("expected request body User clearing readOnly fields, got %+v", api.RequestBody)
+	}
+	if !api.Response.OmitWriteOnly {
+		t.Errorf("expected response %s to leave writeOnly fields out", api.Response.ModelName)
+	}
+	get := findAPIByOperationID(apis, "user", "GetUser")
+	if get == nil {
+		t.Fatalf("expected API GetUser")
+	}
+	if get.Response.Binding != "xml" || !get.Response.OmitWriteOnly {
+		t.Errorf("expected the XML response to leave writeOnly fields out, got %+v", get.Response)
+	}
+}
+
+func TestApplyReadWriteOnly_Variants(t *testing.T) {
+	doc := loadDoc(t, readWriteSpec)
+	apis := MapAPIFromPaths(doc)
+	models := ApplyReadWriteOnly(MapModelsFromSchemas(doc), apis, "variants")
+
+	request := findModel(models, "UserRequest")
+	if request == nil {
+		t.Fatalf("expected model UserRequest to be generated")
+	}
+	if len(request.Fields) != 2 || findField(request.Fields, "Id") {
+		t.Errorf("expected UserRequest without Id, got %+v", request.Fields)
+	}
+	response := findModel(models, "UserResponse")
+	if response == nil || findField(response.Fields, "Password") {
+		t.Fatalf("expected UserResponse without Password, got %+v", response)
+	}
+	team := findModel(models, "TeamResponse")
+	if team == nil {
+		t.Fatalf("expected model TeamResponse to be generated")
+	}
+	assertField(t, team.Fields, "Members", "[]UserResponse", "members")
+	if findModel(models, "TagRequest") != nil || findModel(models, "TagResponse") != nil {
+		t.Errorf("expected no variants of Tag")
+	}
+	var names []string
+	for _, model := range models {
+		names = append(names, model.Name)
+	}
+	want := []string{"Tag", "Team", "User", "TeamRequest", "UserRequest", "TeamResponse", "UserResponse"}
+	if !slices.Equal(names, want) {
+		t.Errorf("expected models %v, got %v", want, names)
+	}
+
+	api := findAPIByOperationID(apis, "user", "AddUser")
+	if api == nil {
+		t.Fatalf("expected API AddUser")
+	}
+	if api.RequestBody.ModelName != "UserRequest" {
+		t.Errorf("expected request body UserRequest, got %s", api.RequestBody.ModelName)
+	}
+	if api.Response.ModelName != "[]TeamResponse" {
+		t.Errorf("expected response []TeamResponse, got %s", api.Response.ModelName)
+	}
+}
+
+func findField(fields []templates.ModelProp, goName string) bool {
+	for _, field := range fields {
+		if field.GoName == goName {
+			return true
+		}
+	}
+	return false
+}
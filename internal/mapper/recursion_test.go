@@ -0,0 +1,171 @@
+package mapper
+
+import (
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopenapi/internal/templates"
+)
+
+func loadDoc(t *testing.T, spec string) *openapi3.T {
+	t.Helper()
+	data, err := NormalizeExclusiveBounds([]byte(spec))
+	if err != nil {
+		t.Fatalf("failed to normalize spec: %v", err)
+	}
+	doc, err := openapi3.NewLoader().LoadFromData(data)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	return doc
+}
+
+func TestMapModelsFromSchemas_SelfReference(t *testing.T) {
+	doc := loadDoc(t, `
+openapi: 3.0.0
+info: {title: t, version: "1"}
+paths: {}
+components:
+  schemas:
+    Node:
+      type: object
+      required: [parent]
+      properties:
+        name: {type: string}
+        parent: {$ref: '#/components/schemas/Node'}
+        children:
+          type: array
+          items: {$ref: '#/components/schemas/Node'}
+        index:
+          type: object
+          additionalProperties: {$ref: '#/components/schemas/Node'}
+`)
+	models := MapModelsFromSchemas(doc)
+	node := findModel(models, "Node")
+	if node == nil {
+		t.Fatalf("expected model Node to be generated")
+	}
+	assertField(t, node.Fields, "Parent", "*Node", "parent")
+	assertField(t, node.Fields, "Children", "[]Node", "children")
+	assertNested(t, node.Fields, "Parent", "pointer")
+	assertNested(t, node.Fields, "Children", "models")
+	assertValidations(t, node.Fields, "Parent", []templates.Validation{{Func: "checkRequired"}})
+}
+
+func TestMapModelsFromSchemas_MutualReference(t *testing.T) {
+	doc := loadDoc(t, `
+openapi: 3.0.0
+info: {title: t, version: "1"}
+paths: {}
+components:
+  schemas:
+    Author:
+      type: object
+      properties:
+        books:
+          type: array
+          items: {$ref: '#/components/schemas/Book'}
+    Book:
+      type: object
+      properties:
+        author: {$ref: '#/components/schemas/Author'}
+`)
+	models := MapModelsFromSchemas(doc)
+	if len(models) != 2 {
+		t.Fatalf("expected only the Author and Book models, got %d models", len(models))
+	}
+
+	author := findModel(models, "Author")
+	if author == nil {
+		t.Fatalf("expected model Author to be generated")
+	}
+	assertField(t, author.Fields, "Books", "[]Book", "books")
+	assertNested(t, author.Fields, "Books", "models")
+
+	book := findModel(models, "Book")
+	if book == nil {
+		t.Fatalf("expected model Book to be generated")
+	}
+	assertField(t, book.Fields, "Author", "*Author", "author")
+	assertNested(t, book.Fields, "Author", "pointer")
+}
+
+func TestMapModelsFromSchemas_DeepCycle(t *testing.T) {
+	doc := loadDoc(t, `
+openapi: 3.0.0
+info: {title: t, version: "1"}
+paths: {}
+components:
+  schemas:
+    A:
+      type: object
+      properties:
+        b: {$ref: '#/components/schemas/B'}
+    B:
+      type: object
+      properties:
+        c: {$ref: '#/components/schemas/C'}
+    C:
+      type: object
+      properties:
+        a: {$ref: '#/components/schemas/A'}
+`)
+	models := MapModelsFromSchemas(doc)
+	if len(models) != 3 {
+		t.Fatalf("expected only the A, B and C models, got %d models", len(models))
+	}
+	assertField(t, findModel(models, "A").Fields, "B", "*B", "b")
+	assertField(t, findModel(models, "B").Fields, "C", "*C", "c")
+	assertField(t, findModel(models, "C").Fields, "A", "*A", "a")
+}
+
+func TestMapModelsFromSchemas_ComponentReference(t *testing.T) {
+	doc := loadDoc(t, `
+openapi: 3.0.0
+info: {title: t, version: "1"}
+paths: {}
+components:
+  schemas:
+    Category:
+      type: object
+      properties:
+        name: {type: string}
+    Status:
+      type: string
+      enum: [available, sold]
+    Pet:
+      type: object
+      properties:
+        category: {$ref: '#/components/schemas/Category'}
+        status: {$ref: '#/components/schemas/Status'}
+        tags:
+          type: array
+          items: {$ref: '#/components/schemas/Category'}
+    Animal: {$ref: '#/components/schemas/Pet'}
+`)
+	models := MapModelsFromSchemas(doc)
+	if len(models) != 3 {
+		t.Fatalf("expected only the Animal, Category and Pet models, got %d models", len(models))
+	}
+	pet := findModel(models, "Pet")
+	assertField(t, pet.Fields, "Category", "Category", "category")
+	assertNested(t, pet.Fields, "Category", "model")
+	assertField(t, pet.Fields, "Status", "string", "status")
+	assertField(t, pet.Fields, "Tags", "[]Category", "tags")
+	if findModel(models, "Animal") == nil {
+		t.Errorf("expected the Animal component to be generated")
+	}
+}
+
+func assertNested(t *testing.T, fields []templates.ModelProp, goName, nested string) {
+	t.Helper()
+	for _, f := range fields {
+		if f.GoName == goName {
+			if f.Nested != nested {
+				t.Fatalf("field %s: expected Nested %q, got %q", goName, nested, f.Nested)
+			}
+			return
+		}
+	}
+	t.Fatalf("expected field %q not found", goName)
+}
@@ -0,0 +1,87 @@
+package mapper
+
+import (
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/iancoleman/strcase"
+	"gopenapi/internal/config"
+	"gopenapi/internal/templates"
+	"strings"
+)
+
+// ApplyStructTags sets the struct tag of every model field, adding the tag
+// families enabled in cfg to the json tag.
+func ApplyStructTags(models []templates.Model, cfg config.StructTags) {
+	for i := range models {
+		for j := range models[i].Fields {
+			models[i].Fields[j].Tags = structTag(models[i].Fields[j], cfg)
+		}
+	}
+}
+
+func structTag(field templates.ModelProp, cfg config.StructTags) string {
+	omitEmpty := ""
+	if field.OmitEmpty {
+		omitEmpty = ",omitempty"
+	}
+	if field.JSONIgnore {
+		tags := []string{`json:"-"`}
+		if cfg.YAML {
+			tags = append(tags, `yaml:"-"`)
+		}
+		return strings.Join(tags, " ")
+	}
+	tags := []string{fmt.Sprintf(`json:"%s%s"`, field.JSONName, omitEmpty)}
+	if cfg.XML {
+		tags = append(tags, fmt.Sprintf(`xml:"%s"`, field.XMLName))
+	}
+	if cfg.YAML {
+		tags = append(tags, fmt.Sprintf(`yaml:"%s%s"`, field.JSONName, omitEmpty))
+	}
+	if cfg.Form {
+		tags = append(tags, fmt.Sprintf(`form:"%s"`, field.JSONName))
+	}
+	if cfg.DB != "" {
+		tags = append(tags, fmt.Sprintf(`db:"%s"`, dbName(field.JSONName, cfg.DB)))
+	}
+	if cfg.Binding && field.Required && !field.Nullable && zeroMeansAbsent(field.GoType) {
+		tags = append(tags, `binding:"required"`)
+	}
+	if cfg.Validate && field.ValidateTag != "" {
+		tags = append(tags, fmt.Sprintf(`validate:"%s"`, field.ValidateTag))
+	}
+	return strings.Join(tags, " ")
+}
+
+func dbName(name, strategy string) string {
+	switch strategy {
+	case "snake":
+		return strcase.ToSnake(name)
+	case "camel":
+		return strcase.ToLowerCamel(name)
+	case "pascal":
+		return strcase.ToCamel(name)
+	default:
+		return name
+	}
+}
+
+// xmlName derives the xml tag value of a property from its xml object:
+// renamed elements, attributes and wrapped arrays as wrapper>item.
+func xmlName(propName string, schema *openapi3.Schema) string {
+	name := propName
+	if schema.XML != nil && schema.XML.Name != "" {
+		name = schema.XML.Name
+	}
+	if schema.XML != nil && schema.XML.Attribute {
+		return name + ",attr"
+	}
+	if schema.XML != nil && schema.XML.Wrapped && schema.Items != nil && schema.Items.Value != nil {
+		item := propName
+		if schema.Items.Value.XML != nil && schema.Items.Value.XML.Name != "" {
+			item = schema.Items.Value.XML.Name
+		}
+		return name + ">" + item
+	}
+	return name
+}
@@ -0,0 +1,118 @@
+package mapper
+
+import (
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopenapi/internal/config"
+	"gopenapi/internal/templates"
+)
+
+func TestApplyStructTags(t *testing.T) {
+	field := templates.ModelProp{
+		GoName:      "PhotoUrls",
+		GoType:      "[]string",
+		JSONName:    "photoUrls",
+		Required:    true,
+		XMLName:     "photoUrls>photoUrl",
+		ValidateTag: "required,min=1",
+	}
+
+	tests := []struct {
+		name string
+		cfg  config.StructTags
+		want string
+	}{
+		{
+			name: "json only by default",
+			want: `json:"photoUrls"`,
+		},
+		{
+			name: "all families",
+			cfg:  config.StructTags{Binding: true, Validate: true, XML: true, YAML: true, Form: true, DB: "snake"},
+			want: `json:"photoUrls" xml:"photoUrls>photoUrl" yaml:"photoUrls" form:"photoUrls" db:"photo_urls" binding:"required" validate:"required,min=1"`,
+		},
+		{
+			name: "db naming strategy",
+			cfg:  config.StructTags{DB: "pascal"},
+			want: `json:"photoUrls" db:"PhotoUrls"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			models := []templates.Model{{Name: "Pet", Fields: []templates.ModelProp{field}}}
+			ApplyStructTags(models, tt.cfg)
+			if got := models[0].Fields[0].Tags; got != tt.want {
+				t.Fatalf("expected tags %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestApplyStructTags_RequiredScalars(t *testing.T) {
+	cfg := config.StructTags{Binding: true}
+	models := []templates.Model{{Name: "Pet", Fields: []templates.ModelProp{
+		{GoName: "Age", GoType: "int", JSONName: "age", Required: true},
+		{GoName: "Alive", GoType: "bool", JSONName: "alive", Required: true},
+		{GoName: "Owner", GoType: "*Owner", JSONName: "owner", Required: true},
+	}}}
+	ApplyStructTags(models, cfg)
+	want := []string{`json:"age"`, `json:"alive"`, `json:"owner" binding:"required"`}
+	for i, field := range models[0].Fields {
+		if field.Tags != want[i] {
+			t.Errorf("%s: expected tags %s, got %s", field.GoName, want[i], field.Tags)
+		}
+	}
+}
+
+func TestXMLName(t *testing.T) {
+	str := &openapi3.Types{openapi3.TypeString}
+	tests := []struct {
+		name   string
+		schema *openapi3.Schema
+		want   string
+	}{
+		{"plain", &openapi3.Schema{Type: str}, "id"},
+		{"renamed", &openapi3.Schema{Type: str, XML: &openapi3.XML{Name: "ID"}}, "ID"},
+		{"attribute", &openapi3.Schema{Type: str, XML: &openapi3.XML{Attribute: true}}, "id,attr"},
+		{"wrapped", &openapi3.Schema{
+			Type:  &openapi3.Types{openapi3.TypeArray},
+			XML:   &openapi3.XML{Wrapped: true},
+			Items: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: str, XML: &openapi3.XML{Name: "item"}}},
+		}, "id>item"},
+	}
+	for _, tt := range tests {
+		if got := xmlName("id", tt.schema); got != tt.want {
+			t.Errorf("%s: xmlName = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTag(t *testing.T) {
+	maxLength := uint64(10)
+	minimum := 1.0
+	tests := []struct {
+		name     string
+		schema   *openapi3.Schema
+		goType   string
+		required bool
+		nested   string
+		want     string
+	}{
+		{"required string", &openapi3.Schema{MinLength: 2, MaxLength: &maxLength}, "string", true, "", "required,min=2,max=10"},
+		{"optional number", &openapi3.Schema{Min: &minimum, ExclusiveMin: true}, "int", false, "", "omitempty,gt=1"},
+		{"required number", &openapi3.Schema{Min: &minimum}, "int", true, "", "gte=1"},
+		{"required boolean", &openapi3.Schema{}, "bool", true, "", ""},
+		{"enum", &openapi3.Schema{Enum: []any{"a", "b c"}}, "string", false, "", "omitempty,oneof=a 'b c'"},
+		{"enum with separators", &openapi3.Schema{Enum: []any{"a,b", "c|d", ""}}, "string", false, "", "omitempty,oneof=a0x2Cb c0x7Cd ''"},
+		{"enum with null", &openapi3.Schema{Enum: []any{"a", nil}}, "string", false, "", "omitempty,oneof=a"},
+		{"enum with quotes", &openapi3.Schema{Enum: []any{"a", `say "hi"`}}, "string", false, "", ""},
+		{"slice of models", &openapi3.Schema{UniqueItems: true}, "[]Tag", false, "models", "omitempty,unique,dive"},
+		{"unconstrained", &openapi3.Schema{}, "string", false, "", ""},
+	}
+	for _, tt := range tests {
+		if got := validateTag(tt.schema, tt.goType, tt.required, tt.nested); got != tt.want {
+			t.Errorf("%s: validateTag = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package mapper
+
+import (
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopenapi/internal/templates"
+	"strconv"
+	"strings"
+)
+
+// mapValidations translates the constraints of a property schema into the
+// checks rendered in the Validate method of its model.
+func mapValidations(schema *openapi3.Schema, goType string, required bool) []templates.Validation {
+	var validations []templates.Validation
+	add := func(fn, args string) {
+		validations = append(validations, templates.Validation{Func: fn, Args: args})
+	}
+
+	isSlice := strings.HasPrefix(goType, "[]")
+	if required && zeroMeansAbsent(goType) {
+		add("checkRequired", "")
+	}
+	switch {
+	case goType == "string":
+		if schema.MinLength > 0 {
+			add("checkMinLength", strconv.FormatUint(schema.MinLength, 10))
+		}
+		if schema.MaxLength != nil {
+			add("checkMaxLength", strconv.FormatUint(*schema.MaxLength, 10))
+		}
+		if schema.Pattern != "" {
+			add("checkPattern", strconv.Quote(schema.Pattern))
+		}
+	case goType == "int" || goType == "float64":
+		if schema.Min != nil {
+			add("checkMinimum", fmt.Sprintf("%v, %t", *schema.Min, schema.ExclusiveMin))
+		}
+		if schema.Max != nil {
+			add("checkMaximum", fmt.Sprintf("%v, %t", *schema.Max, schema.ExclusiveMax))
+		}
+		if schema.MultipleOf != nil {
+			add("checkMultipleOf", fmt.Sprint(*schema.MultipleOf))
+		}
+	case isSlice:
+		if schema.MinItems > 0 {
+			add("checkMinItems", strconv.FormatUint(schema.MinItems, 10))
+		}
+		if schema.MaxItems != nil {
+			add("checkMaxItems", strconv.FormatUint(*schema.MaxItems, 10))
+		}
+		if schema.UniqueItems {
+			add("checkUniqueItems", "")
+		}
+		// Enums of the items, inline or of a referenced schema, are checked
+		// on every item.
+		if items := schema.Items; items != nil && items.Value != nil {
+			itemType := strings.TrimPrefix(goType, "[]")
+			if args := enumArgs(items.Value.Enum, itemType); args != "" {
+				add("checkEachEnum", args)
+			}
+			if value, ok := items.Value.Extensions[kwConst]; ok {
+				if args := enumArgs([]any{value}, itemType); args != "" {
+					add("checkEachEnum", args)
+				}
+			}
+		}
+	}
+	if args := enumArgs(schema.Enum, goType); args != "" {
+		add("checkEnum", args)
+	}
+	if value, ok := schema.Extensions[kwConst]; ok {
+		if args := enumArgs([]any{value}, goType); args != "" {
+			add("checkEnum", args)
+		}
+	}
+	return validations
+}
+
+// zeroMeansAbsent reports whether the zero value of goType stands for an
+// absent value, so that required can be checked on it: strings, slices,
+// maps and pointers. Zero numbers, false and empty models are valid values.
+func zeroMeansAbsent(goType string) bool {
+	return goType == "string" || strings.HasPrefix(goType, "[]") ||
+		strings.HasPrefix(goType, "map[") || strings.HasPrefix(goType, "*")
+}
+
+// ApplyRequiredOnUnmarshal marks the required fields that Validate cannot
+// tell apart from absent ones, numbers, booleans, nested models and nullable
+// fields, to be checked by an UnmarshalJSON method instead. writeOnly fields are left out
+// as responses never hold them.
+func ApplyRequiredOnUnmarshal(models []templates.Model) {
+	for i := range models {
+		for _, field := range models[i].Fields {
+			if !field.Required || field.JSONIgnore || field.WriteOnly {
+				continue
+			}
+			if field.GoType == "int" || field.GoType == "float64" || field.GoType == "bool" || field.Nested == "model" || field.Nullable {
+				models[i].RequiredKeys = append(models[i].RequiredKeys, field.JSONName)
+			}
+		}
+		if len(models[i].RequiredKeys) > 0 {
+			models[i].Imports = uniqueSorted(append(models[i].Imports, "encoding/json"))
+		}
+	}
+}
+
+// enumArgs renders the enum values of a string or number schema as Go
+// literals of goType.
+func enumArgs(enum []any, goType string) string {
+	var values []string
+	for _, v := range enum {
+		if v == nil {
+			// null is allowed by a nullable field and is not a value to check.
+			continue
+		}
+		switch goType {
+		case "string":
+			s, ok := v.(string)
+			if !ok {
+				return ""
+			}
+			values = append(values, strconv.Quote(s))
+		case "int", "float64":
+			n, ok := v.(float64)
+			if !ok {
+				return ""
+			}
+			values = append(values, fmt.Sprint(n))
+		default:
+			return ""
+		}
+	}
+	return strings.Join(values, ", ")
+}
+
+// nestedKind reports whether a property holds a generated model ("model")
+// or a slice of them ("models") that Validate must recurse into.
+func nestedKind(schema *openapi3.SchemaRef) string {
+	if schema == nil || schema.Value == nil || extString(schema.Value.Extensions, extGoType) != "" {
+		return ""
+	}
+	if isType(schema.Value, openapi3.TypeObject) {
+		return "model"
+	}
+	if isType(schema.Value, openapi3.TypeArray) && nestedKind(schema.Value.Items) == "model" {
+		return "models"
+	}
+	return ""
+}
+
+// validateTag derives a go-playground validate tag from the constraints of
+// a property schema. Patterns and multipleOf have no builtin equivalent and
+// are only checked by the generated Validate methods.
+func validateTag(schema *openapi3.Schema, goType string, required bool, nested string) string {
+	var rules []string
+	isSlice := strings.HasPrefix(goType, "[]")
+	switch {
+	case goType == "string" || isSlice:
+		minimum, maximum := schema.MinLength, schema.MaxLength
+		if isSlice {
+			minimum, maximum = schema.MinItems, schema.MaxItems
+		}
+		if minimum > 0 {
+			rules = append(rules, fmt.Sprintf("min=%d", minimum))
+		}
+		if maximum != nil {
+			rules = append(rules, fmt.Sprintf("max=%d", *maximum))
+		}
+		if isSlice && schema.UniqueItems {
+			rules = append(rules, "unique")
+		}
+	case goType == "int" || goType == "float64":
+		if schema.Min != nil {
+			op := "gte"
+			if schema.ExclusiveMin {
+				op = "gt"
+			}
+			rules = append(rules, fmt.Sprintf("%s=%v", op, *schema.Min))
+		}
+		if schema.Max != nil {
+			op := "lte"
+			if schema.ExclusiveMax {
+				op = "lt"
+			}
+			rules = append(rules, fmt.Sprintf("%s=%v", op, *schema.Max))
+		}
+	}
+	if len(schema.Enum) > 0 && enumArgs(schema.Enum, goType) != "" {
+		var values []string
+		for _, v := range schema.Enum {
+			if v == nil {
+				continue
+			}
+			value, ok := oneofValue(v)
+			if !ok {
+				// Diagnose reports the enum, Validate still checks it.
+				values = nil
+				break
+			}
+			values = append(values, value)
+		}
+		if values != nil {
+			rules = append(rules, "oneof="+strings.Join(values, " "))
+		}
+	}
+	if nested == "models" {
+		rules = append(rules, "dive")
+	}
+	switch {
+	case required && zeroMeansAbsent(goType):
+		return strings.Join(append([]string{"required"}, rules...), ",")
+	case required:
+		// The zero value is valid, so it is checked like any other.
+		return strings.Join(rules, ",")
+	case len(rules) == 0:
+		return ""
+	}
+	return strings.Join(append([]string{"omitempty"}, rules...), ",")
+}
+
+// oneofValue renders an enum value as a parameter of the oneof rule: commas
+// and pipes are escaped as the validator expects, empty values and values
+// with spaces are quoted. It returns false when the tag cannot hold the value.
+func oneofValue(v any) (string, bool) {
+	value := fmt.Sprint(v)
+	if strings.ContainsAny(value, "\"`\\") ||
+		strings.Contains(value, "'") && (strings.ContainsAny(value, " \t\n") || value[0] == '\'') {
+		return "", false
+	}
+	value = strings.NewReplacer(",", "0x2C", "|", "0x7C").Replace(value)
+	if value == "" || strings.ContainsAny(value, " \t\n") {
+		value = "'" + value + "'"
+	}
+	return value, true
+}
@@ -0,0 +1,135 @@
+package mapper
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopenapi/internal/templates"
+)
+
+func TestMapModelsFromSchemas_Validations(t *testing.T) {
+	maxLength := uint64(5)
+	minimum, maximum := 0.0, 150.0
+	color := &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}, Enum: []any{"red", "green"}}
+	doc := &openapi3.T{Components: &openapi3.Components{Schemas: openapi3.Schemas{
+		"Color": {Value: color},
+		"Person": {Value: &openapi3.Schema{
+			Type:     &openapi3.Types{openapi3.TypeObject},
+			Required: []string{"name", "address"},
+			Properties: openapi3.Schemas{
+				"name": {Value: &openapi3.Schema{
+					Type: &openapi3.Types{openapi3.TypeString}, MinLength: 2, MaxLength: &maxLength, Pattern: "^[a-z]+$",
+				}},
+				"age": {Value: &openapi3.Schema{
+					Type: &openapi3.Types{openapi3.TypeInteger}, Min: &minimum, Max: &maximum, ExclusiveMax: true,
+				}},
+				"status": {Value: &openapi3.Schema{
+					Type: &openapi3.Types{openapi3.TypeString}, Enum: []any{"active", "banned"},
+				}},
+				"tags": {Value: &openapi3.Schema{
+					Type: &openapi3.Types{openapi3.TypeArray}, MinItems: 1, UniqueItems: true,
+					Items: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}}},
+				}},
+				"labels": {Value: &openapi3.Schema{
+					Type:  &openapi3.Types{openapi3.TypeArray},
+					Items: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}, Enum: []any{"new", "hot"}}},
+				}},
+				"colors": {Value: &openapi3.Schema{
+					Type:  &openapi3.Types{openapi3.TypeArray},
+					Items: &openapi3.SchemaRef{Ref: "#/components/schemas/Color", Value: color},
+				}},
+				"address": {Value: &openapi3.Schema{
+					Type:       &openapi3.Types{openapi3.TypeObject},
+					Properties: openapi3.Schemas{"city": {Value: &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}}}},
+				}},
+			},
+		}},
+	}}}
+
+	person := findModel(MapModelsFromSchemas(doc), "Person")
+	if person == nil {
+		t.Fatalf("expected model Person to be generated")
+	}
+
+	assertValidations(t, person.Fields, "Name", []templates.Validation{
+		{Func: "checkRequired"},
+		{Func: "checkMinLength", Args: "2"},
+		{Func: "checkMaxLength", Args: "5"},
+		{Func: "checkPattern", Args: `"^[a-z]+$"`},
+	})
+	assertValidations(t, person.Fields, "Age", []templates.Validation{
+		{Func: "checkMinimum", Args: "0, false"},
+		{Func: "checkMaximum", Args: "150, true"},
+	})
+	assertValidations(t, person.Fields, "Status", []templates.Validation{
+		{Func: "checkEnum", Args: `"active", "banned"`},
+	})
+	assertValidations(t, person.Fields, "Tags", []templates.Validation{
+		{Func: "checkMinItems", Args: "1"},
+		{Func: "checkUniqueItems"},
+	})
+	assertValidations(t, person.Fields, "Labels", []templates.Validation{
+		{Func: "checkEachEnum", Args: `"new", "hot"`},
+	})
+	assertValidations(t, person.Fields, "Colors", []templates.Validation{
+		{Func: "checkEachEnum", Args: `"red", "green"`},
+	})
+
+	for _, f := range person.Fields {
+		switch f.GoName {
+		case "Address":
+			if !f.Required || f.Nested != "model" {
+				t.Errorf("expected Address to be a required nested model, got %+v", f)
+			}
+		case "Age":
+			if f.Required || f.Nested != "" {
+				t.Errorf("expected Age to be optional and not nested, got %+v", f)
+			}
+		}
+	}
+}
+
+func TestApplyRequiredOnUnmarshal(t *testing.T) {
+	models := []templates.Model{
+		{Name: "Order", Fields: []templates.ModelProp{
+			{GoName: "Address", GoType: "Address", JSONName: "address", Required: true, Nested: "model"},
+			{GoName: "Count", GoType: "int", JSONName: "count", Required: true},
+			{GoName: "Gift", GoType: "bool", JSONName: "gift", Required: true},
+			{GoName: "Name", GoType: "string", JSONName: "name", Required: true},
+			{GoName: "Pin", GoType: "int", JSONName: "pin", Required: true, WriteOnly: true},
+			{GoName: "Price", GoType: "float64", JSONName: "price"},
+		}},
+		{Name: "Address", Fields: []templates.ModelProp{
+			{GoName: "City", GoType: "string", JSONName: "city", Required: true},
+		}},
+	}
+	ApplyRequiredOnUnmarshal(models)
+
+	want := []string{"address", "count", "gift"}
+	if !slices.Equal(models[0].RequiredKeys, want) || !slices.Contains(models[0].Imports, "encoding/json") {
+		t.Errorf("expected Order to check %v on unmarshal, got %+v", want, models[0])
+	}
+	if models[1].RequiredKeys != nil || models[1].Imports != nil {
+		t.Errorf("expected Address to be left as is, got %+v", models[1])
+	}
+}
+
+func assertValidations(t *testing.T, fields []templates.ModelProp, goName string, want []templates.Validation) {
+	t.Helper()
+	for _, f := range fields {
+		if f.GoName != goName {
+			continue
+		}
+		if len(f.Validations) != len(want) {
+			t.Fatalf("field %s: expected validations %+v, got %+v", goName, want, f.Validations)
+		}
+		for i := range want {
+			if f.Validations[i] != want[i] {
+				t.Errorf("field %s: validation %d: expected %+v, got %+v", goName, i, want[i], f.Validations[i])
+			}
+		}
+		return
+	}
+	t.Fatalf("expected field %q not found", goName)
+}
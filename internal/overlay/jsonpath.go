@@ -0,0 +1,568 @@
+package overlay
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// path is a compiled JSONPath query, a subset of RFC 9535: name, index and
+// wildcard selectors, descendant segments and filters comparing the
+// members of the current node with literals, e.g.
+// $.paths['/pets'].*[?@.operationId == 'listPets'].
+type path []segment
+
+type segment struct {
+	// descendant applies the selectors to the node and all its descendants.
+	descendant bool
+	selectors  []selector
+}
+
+type selectorKind int
+
+const (
+	nameSelector selectorKind = iota
+	wildcardSelector
+	indexSelector
+	filterSelector
+)
+
+type selector struct {
+	kind   selectorKind
+	name   string
+	index  int
+	filter expr
+}
+
+// match is a node selected by a query, with where it sits in its parent so
+// it can be removed: the index of its key in a mapping, or its index in a
+// sequence. The root has no parent.
+type match struct {
+	parent *yaml.Node
+	index  int
+	node   *yaml.Node
+}
+
+// find returns the nodes selected from root, without duplicates.
+func (p path) find(root *yaml.Node) []match {
+	matches := []match{{node: root}}
+	for _, seg := range p {
+		var next []match
+		for _, m := range matches {
+			nodes := []match{m}
+			if seg.descendant {
+				nodes = descendants(m, nodes)
+			}
+			for _, n := range nodes {
+				for _, sel := range seg.selectors {
+					next = append(next, sel.apply(n.node)...)
+				}
+			}
+		}
+		matches = next
+	}
+	seen := map[*yaml.Node]bool{}
+	unique := matches[:0]
+	for _, m := range matches {
+		if !seen[m.node] {
+			seen[m.node] = true
+			unique = append(unique, m)
+		}
+	}
+	return unique
+}
+
+func descendants(m match, list []match) []match {
+	for _, child := range children(m.node) {
+		list = descendants(child, append(list, child))
+	}
+	return list
+}
+
+func children(node *yaml.Node) []match {
+	var list []match
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			list = append(list, match{parent: node, index: i, node: node.Content[i+1]})
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			list = append(list, match{parent: node, index: i, node: child})
+		}
+	}
+	return list
+}
+
+// keyIndex returns the index of key among the contents of a mapping, -1
+// when it is absent.
+func keyIndex(node *yaml.Node, key string) int {
+	if node.Kind != yaml.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s selector) apply(node *yaml.Node) []match {
+	switch s.kind {
+	case nameSelector:
+		if i := keyIndex(node, s.name); i >= 0 {
+			return []match{{parent: node, index: i, node: node.Content[i+1]}}
+		}
+	case wildcardSelector:
+		return children(node)
+	case indexSelector:
+		if node.Kind == yaml.SequenceNode {
+			i := s.index
+			if i < 0 {
+				i += len(node.Content)
+			}
+			if i >= 0 && i < len(node.Content) {
+				return []match{{parent: node, index: i, node: node.Content[i]}}
+			}
+		}
+	case filterSelector:
+		var list []match
+		for _, child := range children(node) {
+			if s.filter.eval(child.node) {
+				list = append(list, child)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// expr is a filter expression, evaluated against each child of the
+// filtered node.
+type expr interface {
+	eval(node *yaml.Node) bool
+}
+
+type (
+	orExpr      []expr
+	andExpr     []expr
+	notExpr     struct{ expr }
+	existsExpr  struct{ query path }
+	compareExpr struct {
+		op          string
+		left, right operand
+	}
+)
+
+func (e orExpr) eval(node *yaml.Node) bool {
+	for _, x := range e {
+		if x.eval(node) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e andExpr) eval(node *yaml.Node) bool {
+	for _, x := range e {
+		if !x.eval(node) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e notExpr) eval(node *yaml.Node) bool {
+	return !e.expr.eval(node)
+}
+
+func (e existsExpr) eval(node *yaml.Node) bool {
+	return len(e.query.find(node)) > 0
+}
+
+func (e compareExpr) eval(node *yaml.Node) bool {
+	a, b := e.left.value(node), e.right.value(node)
+	switch e.op {
+	case "==":
+		return a == b && a.kind != nodeValue
+	case "!=":
+		return a != b || a.kind == nodeValue
+	}
+	if a.kind != b.kind {
+		return false
+	}
+	var c int
+	switch a.kind {
+	case numberValue:
+		c = cmp.Compare(a.num, b.num)
+	case stringValue:
+		c = cmp.Compare(a.str, b.str)
+	default:
+		return false
+	}
+	switch e.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	}
+	return c >= 0
+}
+
+// operand is a query relative to the filtered node, or a literal when
+// query is nil.
+type operand struct {
+	query   path
+	literal value
+}
+
+type valueKind int
+
+const (
+	nothing valueKind = iota
+	stringValue
+	numberValue
+	boolValue
+	nullValue
+	// nodeValue is a mapping or a sequence, which only compares unequal.
+	nodeValue
+)
+
+type value struct {
+	kind valueKind
+	str  string
+	num  float64
+}
+
+func (o operand) value(node *yaml.Node) value {
+	if o.query == nil {
+		return o.literal
+	}
+	matches := o.query.find(node)
+	if len(matches) == 0 {
+		return value{}
+	}
+	return nodeToValue(matches[0].node)
+}
+
+func nodeToValue(node *yaml.Node) value {
+	if node.Kind != yaml.ScalarNode {
+		return value{kind: nodeValue}
+	}
+	switch node.ShortTag() {
+	case "!!int", "!!float":
+		if num, err := strconv.ParseFloat(node.Value, 64); err == nil {
+			return value{kind: numberValue, num: num}
+		}
+	case "!!bool":
+		b, _ := strconv.ParseBool(node.Value)
+		return value{kind: boolValue, str: strconv.FormatBool(b)}
+	case "!!null":
+		return value{kind: nullValue}
+	}
+	return value{kind: stringValue, str: node.Value}
+}
+
+// compile parses a JSONPath query.
+func compile(query string) (path, error) {
+	p := &parser{s: query}
+	if !p.consume("$") {
+		return nil, p.errorf("query must start with $")
+	}
+	segments, err := p.segments()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.pos:])
+	}
+	return segments, nil
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%s at offset %d of %s", fmt.Sprintf(format, args...), p.pos, p.s)
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) consume(token string) bool {
+	if strings.HasPrefix(p.s[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\n\r", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *parser) segments() (path, error) {
+	var segments path
+	for {
+		var seg segment
+		switch {
+		case p.consume(".."):
+			seg.descendant = true
+			if p.peek() != '[' {
+				sel, err := p.dotSelector()
+				if err != nil {
+					return nil, err
+				}
+				seg.selectors = []selector{sel}
+				break
+			}
+			fallthrough
+		case p.peek() == '[':
+			sels, err := p.bracket()
+			if err != nil {
+				return nil, err
+			}
+			seg.selectors = sels
+		case p.consume("."):
+			sel, err := p.dotSelector()
+			if err != nil {
+				return nil, err
+			}
+			seg.selectors = []selector{sel}
+		default:
+			return segments, nil
+		}
+		segments = append(segments, seg)
+	}
+}
+
+// dotSelector parses the wildcard or member name following a dot.
+func (p *parser) dotSelector() (selector, error) {
+	if p.consume("*") {
+		return selector{kind: wildcardSelector}, nil
+	}
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte(".[]() \t\n\r=!<>&|,", p.s[p.pos]) < 0 {
+		p.pos++
+	}
+	if p.pos == start {
+		return selector{}, p.errorf("expected a member name")
+	}
+	return selector{kind: nameSelector, name: p.s[start:p.pos]}, nil
+}
+
+// bracket parses a comma separated list of selectors between brackets.
+func (p *parser) bracket() ([]selector, error) {
+	p.consume("[")
+	var sels []selector
+	for {
+		p.skipSpace()
+		var sel selector
+		switch c := p.peek(); {
+		case c == '\'' || c == '"':
+			name, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			sel = selector{kind: nameSelector, name: name}
+		case c == '*':
+			p.pos++
+			sel = selector{kind: wildcardSelector}
+		case c == '?':
+			p.pos++
+			filter, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			sel = selector{kind: filterSelector, filter: filter}
+		case c == '-' || c >= '0' && c <= '9':
+			start := p.pos
+			p.pos++
+			for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+				p.pos++
+			}
+			index, err := strconv.Atoi(p.s[start:p.pos])
+			if err != nil {
+				return nil, p.errorf("invalid index %q", p.s[start:p.pos])
+			}
+			sel = selector{kind: indexSelector, index: index}
+		default:
+			return nil, p.errorf("expected a selector")
+		}
+		sels = append(sels, sel)
+		p.skipSpace()
+		if p.consume("]") {
+			return sels, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected , or ]")
+		}
+	}
+}
+
+// quoted parses a string literal in single or double quotes.
+func (p *parser) quoted() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+		switch c {
+		case quote:
+			return b.String(), nil
+		case '\\':
+			if p.pos == len(p.s) {
+				break
+			}
+			c = p.s[p.pos]
+			p.pos++
+			switch c {
+			case 'n':
+				c = '\n'
+			case 't':
+				c = '\t'
+			case 'r':
+				c = '\r'
+			}
+		}
+		b.WriteByte(c)
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *parser) or() (expr, error) {
+	var list orExpr
+	for {
+		e, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+		p.skipSpace()
+		if !p.consume("||") {
+			break
+		}
+	}
+	if len(list) == 1 {
+		return list[0], nil
+	}
+	return list, nil
+}
+
+func (p *parser) and() (expr, error) {
+	var list andExpr
+	for {
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+		p.skipSpace()
+		if !p.consume("&&") {
+			break
+		}
+	}
+	if len(list) == 1 {
+		return list[0], nil
+	}
+	return list, nil
+}
+
+func (p *parser) unary() (expr, error) {
+	p.skipSpace()
+	switch {
+	case p.consume("!"):
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{e}, nil
+	case p.consume("("):
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume(")") {
+			return nil, p.errorf("expected )")
+		}
+		return e, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (expr, error) {
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	var op string
+	for _, candidate := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		if left.query == nil {
+			return nil, p.errorf("expected a comparison")
+		}
+		return existsExpr{left.query}, nil
+	}
+	p.skipSpace()
+	right, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	return compareExpr{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) operand() (operand, error) {
+	switch c := p.peek(); {
+	case c == '@':
+		p.pos++
+		query, err := p.segments()
+		if err != nil {
+			return operand{}, err
+		}
+		return operand{query: query}, nil
+	case c == '\'' || c == '"':
+		s, err := p.quoted()
+		if err != nil {
+			return operand{}, err
+		}
+		return operand{literal: value{kind: stringValue, str: s}}, nil
+	case p.consume("true"):
+		return operand{literal: value{kind: boolValue, str: "true"}}, nil
+	case p.consume("false"):
+		return operand{literal: value{kind: boolValue, str: "false"}}, nil
+	case p.consume("null"):
+		return operand{literal: value{kind: nullValue}}, nil
+	}
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("+-.0123456789eE", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	num, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+	if err != nil {
+		p.pos = start
+		return operand{}, p.errorf("expected a relative query or a literal")
+	}
+	return operand{literal: value{kind: numberValue, num: num}}, nil
+}
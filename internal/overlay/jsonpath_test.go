@@ -0,0 +1,70 @@
+package overlay
+
+import (
+	"slices"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const pathsDoc = `
+paths:
+  /pets:
+    get: {operationId: listPets, tags: [pets], x-limit: 10}
+    post: {operationId: addPet, tags: [pets], deprecated: true}
+  /users/{id}:
+    get: {operationId: getUser, tags: [users], x-limit: 2}
+`
+
+func TestCompile_Find(t *testing.T) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(pathsDoc), &doc); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"$.paths['/pets'].get.operationId", []string{"listPets"}},
+		{`$.paths["/users/{id}"].*.operationId`, []string{"getUser"}},
+		{"$.paths.*.*.operationId", []string{"listPets", "addPet", "getUser"}},
+		{"$..operationId", []string{"listPets", "addPet", "getUser"}},
+		{"$.paths.*[?@.operationId == 'addPet'].operationId", []string{"addPet"}},
+		{"$.paths.*[?(@.deprecated)].operationId", []string{"addPet"}},
+		{"$.paths.*[?!@.deprecated && @.tags[0] == 'pets'].operationId", []string{"listPets"}},
+		{"$.paths.*[?@['x-limit'] > 5 || @.operationId == 'getUser'].operationId", []string{"listPets", "getUser"}},
+		{"$.paths.*.*.tags[-1]", []string{"pets", "pets", "users"}},
+		{"$.paths['/pets']['get','post'].operationId", []string{"listPets", "addPet"}},
+		{"$.paths.missing", nil},
+	}
+	for _, tt := range tests {
+		path, err := compile(tt.query)
+		if err != nil {
+			t.Errorf("compile(%s): %v", tt.query, err)
+			continue
+		}
+		var got []string
+		for _, m := range path.find(doc.Content[0]) {
+			got = append(got, m.node.Value)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.query, tt.want, got)
+		}
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	for _, query := range []string{
+		"paths.pets",
+		"$.",
+		"$.paths[",
+		"$.paths['pets",
+		"$.paths[?@.a ==]",
+		"$.paths[?'a']",
+		"$.paths]",
+	} {
+		if _, err := compile(query); err == nil {
+			t.Errorf("expected %s to be rejected", query)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// Package overlay applies OpenAPI Overlay 1.0 documents, which patch an
+// OpenAPI document with actions targeting its nodes by JSONPath, so specs
+// that cannot be edited can still be adjusted before generation.
+package overlay
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Overlay is an overlay document.
+type Overlay struct {
+	Overlay string `yaml:"overlay"`
+	Info    struct {
+		Title   string `yaml:"title"`
+		Version string `yaml:"version"`
+	} `yaml:"info"`
+	// Extends is the URL of the document the overlay was written for, only
+	// informative here.
+	Extends string   `yaml:"extends"`
+	Actions []Action `yaml:"actions"`
+}
+
+// Action updates or removes the nodes selected by its target.
+type Action struct {
+	Target      string `yaml:"target"`
+	Description string `yaml:"description"`
+	// Update is merged into the targets: mappings are merged recursively,
+	// other values of the same key are replaced. A sequence target gets
+	// the update appended, or its items when it is a sequence too, and a
+	// scalar target is replaced.
+	Update yaml.Node `yaml:"update"`
+	Remove bool      `yaml:"remove"`
+
+	path path
+}
+
+// Parse parses an overlay document in YAML or JSON, and checks its version
+// and targets.
+func Parse(data []byte) (*Overlay, error) {
+	var o Overlay
+	if err := yaml.Unmarshal(data, &o); err != nil {
+		return nil, err
+	}
+	if o.Overlay != "1.0" && !strings.HasPrefix(o.Overlay, "1.0.") {
+		return nil, fmt.Errorf("unsupported overlay version %q, expected 1.0.x", o.Overlay)
+	}
+	if o.Info.Title == "" || o.Info.Version == "" {
+		return nil, errors.New("info requires a title and a version")
+	}
+	if len(o.Actions) == 0 {
+		return nil, errors.New("actions are required")
+	}
+	for i := range o.Actions {
+		action := &o.Actions[i]
+		if action.Target == "" {
+			return nil, fmt.Errorf("action %d: target is required", i+1)
+		}
+		if !action.Remove && action.Update.Kind == 0 {
+			return nil, fmt.Errorf("action %d: update or remove is required", i+1)
+		}
+		path, err := compile(action.Target)
+		if err != nil {
+			return nil, fmt.Errorf("action %d: invalid target: %w", i+1, err)
+		}
+		action.path = path
+	}
+	return &o, nil
+}
+
+// Apply applies the actions in order to the document node and returns a
+// warning per action whose target selects nothing.
+func (o *Overlay) Apply(doc *yaml.Node) ([]string, error) {
+	root := doc
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	var warnings []string
+	for i, action := range o.Actions {
+		matches := action.path.find(root)
+		if len(matches) == 0 {
+			warnings = append(warnings, fmt.Sprintf("action %d: target %s selects nothing", i+1, action.Target))
+			continue
+		}
+		if action.Remove {
+			if err := remove(matches); err != nil {
+				return warnings, fmt.Errorf("action %d: %w", i+1, err)
+			}
+			continue
+		}
+		for _, m := range matches {
+			merge(m.node, &action.Update)
+		}
+	}
+	return warnings, nil
+}
+
+// remove removes the matches from their parents, last first so the indices
+// of the others stay valid.
+func remove(matches []match) error {
+	slices.SortFunc(matches, func(a, b match) int { return b.index - a.index })
+	for _, m := range matches {
+		switch {
+		case m.parent == nil:
+			return errors.New("cannot remove the document root")
+		case m.parent.Kind == yaml.MappingNode:
+			m.parent.Content = slices.Delete(m.parent.Content, m.index, m.index+2)
+		default:
+			m.parent.Content = slices.Delete(m.parent.Content, m.index, m.index+1)
+		}
+	}
+	return nil
+}
+
+func merge(target, update *yaml.Node) {
+	switch {
+	case target.Kind == yaml.MappingNode && update.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(update.Content); i += 2 {
+			key, value := update.Content[i], update.Content[i+1]
+			j := keyIndex(target, key.Value)
+			switch {
+			case j < 0:
+				target.Content = append(target.Content, clone(key), clone(value))
+			case target.Content[j+1].Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+				merge(target.Content[j+1], value)
+			default:
+				target.Content[j+1] = clone(value)
+			}
+		}
+	case target.Kind == yaml.SequenceNode && update.Kind == yaml.SequenceNode:
+		for _, item := range update.Content {
+			target.Content = append(target.Content, clone(item))
+		}
+	case target.Kind == yaml.SequenceNode:
+		target.Content = append(target.Content, clone(update))
+	default:
+		*target = *clone(update)
+	}
+}
+
+// clone copies a node, so the update of an action applied to several
+// targets is not shared between them.
+func clone(node *yaml.Node) *yaml.Node {
+	copied := *node
+	copied.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		copied.Content[i] = clone(child)
+	}
+	return &copied
+}
+
+// ApplyFiles applies the overlay files in order to a YAML or JSON document
+// and returns the patched document as YAML, with a warning per action
+// whose target selects nothing.
+func ApplyFiles(data []byte, paths []string) ([]byte, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+	var warnings []string
+	for _, path := range paths {
+		overlayData, err := os.ReadFile(path)
+		if err != nil {
+			return nil, warnings, fmt.Errorf("failed to read overlay: %w", err)
+		}
+		o, err := Parse(overlayData)
+		if err != nil {
+			return nil, warnings, fmt.Errorf("invalid overlay %s: %w", path, err)
+		}
+		applied, err := o.Apply(&doc)
+		for _, warning := range applied {
+			warnings = append(warnings, fmt.Sprintf("overlay %s: %s", path, warning))
+		}
+		if err != nil {
+			return nil, warnings, fmt.Errorf("failed to apply overlay %s: %w", path, err)
+		}
+	}
+	// JSON documents are written back in the usual YAML style rather than
+	// as flow mappings of quoted strings.
+	if len(doc.Content) > 0 && doc.Content[0].Style&yaml.FlowStyle != 0 {
+		plainStyle(&doc)
+	}
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, warnings, err
+	}
+	return out.Bytes(), warnings, nil
+}
+
+func plainStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		plainStyle(child)
+	}
+}
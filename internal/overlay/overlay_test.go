@@ -0,0 +1,149 @@
+package overlay
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const spec = `openapi: 3.0.3
+info:
+  title: vendor
+  version: "1"
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      tags: [pets]
+    delete:
+      operationId: deletePets
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id: {type: integer}
+`
+
+func TestApply(t *testing.T) {
+	o, err := Parse([]byte(`
+overlay: 1.0.0
+info: {title: fixes, version: "1"}
+actions:
+  - target: $.paths.*.get
+    update:
+      x-go-name: ListAll
+      tags: [animals]
+  - target: $.paths.*.get.tags
+    update: pets
+  - target: $.paths.*[?@.operationId == 'deletePets']
+    remove: true
+  - target: $.components.schemas.Pet.properties.id
+    update: {type: string, format: uuid}
+  - target: $.components.schemas.Owner
+    update: {type: object}
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(spec), &doc); err != nil {
+		t.Fatal(err)
+	}
+	warnings, err := o.Apply(&doc)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "action 5") {
+		t.Errorf("expected a warning for action 5, got %v", warnings)
+	}
+
+	var got struct {
+		Paths map[string]map[string]struct {
+			OperationID string   `yaml:"operationId"`
+			GoName      string   `yaml:"x-go-name"`
+			Tags        []string `yaml:"tags"`
+		} `yaml:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]string `yaml:"properties"`
+			} `yaml:"schemas"`
+		} `yaml:"components"`
+	}
+	if err := doc.Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	pets := got.Paths["/pets"]
+	if _, ok := pets["delete"]; ok || len(pets) != 1 {
+		t.Errorf("expected only get to be left, got %v", pets)
+	}
+	get := pets["get"]
+	if get.OperationID != "listPets" || get.GoName != "ListAll" {
+		t.Errorf("expected x-go-name to be merged into get, got %+v", get)
+	}
+	if strings.Join(get.Tags, ",") != "animals,pets" {
+		t.Errorf("expected tags to be replaced then appended to, got %v", get.Tags)
+	}
+	if id := got.Components.Schemas["Pet"].Properties["id"]; id["type"] != "string" || id["format"] != "uuid" {
+		t.Errorf("expected id to become a uuid string, got %v", id)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		overlay string
+		want    string
+	}{
+		{"overlay: 2.0.0\ninfo: {title: t, version: '1'}\nactions: [{target: $, remove: true}]", "unsupported overlay version"},
+		{"overlay: 1.0.0\ninfo: {title: t}\nactions: [{target: $, remove: true}]", "info requires"},
+		{"overlay: 1.0.0\ninfo: {title: t, version: '1'}", "actions are required"},
+		{"overlay: 1.0.0\ninfo: {title: t, version: '1'}\nactions: [{remove: true}]", "target is required"},
+		{"overlay: 1.0.0\ninfo: {title: t, version: '1'}\nactions: [{target: $}]", "update or remove"},
+		{"overlay: 1.0.0\ninfo: {title: t, version: '1'}\nactions: [{target: '$.a[', remove: true}]", "invalid target"},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.overlay))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("expected an error containing %q, got %v", tt.want, err)
+		}
+	}
+}
+
+func TestApplyFiles(t *testing.T) {
+	tmp := t.TempDir()
+	first := filepath.Join(tmp, "first.yaml")
+	second := filepath.Join(tmp, "second.json")
+	writeFile(t, first, "overlay: 1.0.0\ninfo: {title: t, version: '1'}\nactions:\n  - target: $.info\n    update: {title: patched}\n")
+	writeFile(t, second, `{"overlay": "1.0.0", "info": {"title": "t", "version": "1"}, "actions": [{"target": "$.info.title", "update": "patched twice"}]}`)
+
+	data, warnings, err := ApplyFiles([]byte(`{"openapi": "3.0.3", "info": {"title": "vendor", "version": "1"}}`), []string{first, second})
+	if err != nil {
+		t.Fatalf("ApplyFiles: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+	want := "openapi: 3.0.3\ninfo:\n  title: patched twice\n  version: \"1\"\n"
+	if string(data) != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, data)
+	}
+
+	if _, _, err := ApplyFiles([]byte(spec), []string{filepath.Join(tmp, "missing.yaml")}); err == nil {
+		t.Errorf("expected an error for a missing overlay")
+	}
+	root := filepath.Join(tmp, "root.yaml")
+	writeFile(t, root, "overlay: 1.0.0\ninfo: {title: t, version: '1'}\nactions: [{target: $, remove: true}]\n")
+	if _, _, err := ApplyFiles([]byte(spec), []string{root}); err == nil || !strings.Contains(err.Error(), "root") {
+		t.Errorf("expected removing the root to fail, got %v", err)
+	}
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,94 @@
+package specdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formats lists the report formats accepted by Write.
+var Formats = []string{"text", "json", "markdown"}
+
+// Write writes a report of changes to w in the given format.
+func Write(w io.Writer, changes []Change, format string) error {
+	switch format {
+	case "text":
+		return writeText(w, changes)
+	case "json":
+		return writeJSON(w, changes)
+	case "markdown":
+		return writeMarkdown(w, changes)
+	}
+	return fmt.Errorf("unknown report format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+func writeText(w io.Writer, changes []Change) error {
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(w, "No changes")
+		return err
+	}
+	for _, change := range changes {
+		if _, err := fmt.Fprintf(w, "%-12s  %s: %s\n", level(change), change.Location, change.Message); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%s\n", summary(changes))
+	return err
+}
+
+func writeJSON(w io.Writer, changes []Change) error {
+	report := struct {
+		Breaking int      `json:"breaking"`
+		Changes  []Change `json:"changes"`
+	}{
+		Breaking: Breaking(changes),
+		Changes:  changes,
+	}
+	if report.Changes == nil {
+		report.Changes = []Change{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func writeMarkdown(w io.Writer, changes []Change) error {
+	var out strings.Builder
+	out.WriteString("## API changes\n\n")
+	if len(changes) == 0 {
+		out.WriteString("No changes.\n")
+	} else {
+		fmt.Fprintf(&out, "%s.\n\n", summary(changes))
+		out.WriteString("| Level | Location | Change |\n| --- | --- | --- |\n")
+		for _, change := range changes {
+			level := level(change)
+			if change.Breaking {
+				level = "**" + level + "**"
+			}
+			fmt.Fprintf(&out, "| %s | `%s` | %s |\n", level, markdownCell(change.Location), markdownCell(change.Message))
+		}
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// summary counts the changes and the breaking ones, e.g. "1 change, 1 breaking".
+func summary(changes []Change) string {
+	noun := "changes"
+	if len(changes) == 1 {
+		noun = "change"
+	}
+	return fmt.Sprintf("%d %s, %d breaking", len(changes), noun, Breaking(changes))
+}
+
+func level(change Change) string {
+	if change.Breaking {
+		return "breaking"
+	}
+	return "non-breaking"
+}
+
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
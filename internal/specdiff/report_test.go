@@ -0,0 +1,76 @@
+package specdiff
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var reportChanges = []Change{
+	{Location: "GET /pets parameter limit", Message: "became required", Breaking: true},
+	{Location: "GET /pets response 200 application/json/properties/tag", Message: "optional property added"},
+}
+
+func TestWrite_Text(t *testing.T) {
+	var out strings.Builder
+	if err := Write(&out, reportChanges, "text"); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	want := `breaking      GET /pets parameter limit: became required
+non-breaking  GET /pets response 200 application/json/properties/tag: optional property added
+
+2 changes, 1 breaking
+`
+	if out.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, out.String())
+	}
+}
+
+func TestWrite_TextSingleChange(t *testing.T) {
+	var out strings.Builder
+	if err := Write(&out, reportChanges[:1], "text"); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), "\n1 change, 1 breaking\n") {
+		t.Errorf("expected a singular summary, got\n%s", out.String())
+	}
+}
+
+func TestWrite_Markdown(t *testing.T) {
+	var out strings.Builder
+	if err := Write(&out, reportChanges, "markdown"); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	for _, want := range []string{
+		"2 changes, 1 breaking.",
+		"| **breaking** | `GET /pets parameter limit` | became required |",
+		"| non-breaking | `GET /pets response 200 application/json/properties/tag` | optional property added |",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in\n%s", want, out.String())
+		}
+	}
+}
+
+func TestWrite_JSON(t *testing.T) {
+	var out strings.Builder
+	if err := Write(&out, nil, "json"); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	var report struct {
+		Breaking int      `json:"breaking"`
+		Changes  []Change `json:"changes"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &report); err != nil {
+		t.Fatalf("invalid JSON report: %v", err)
+	}
+	if report.Breaking != 0 || report.Changes == nil || len(report.Changes) != 0 {
+		t.Errorf("expected an empty list of changes, got %s", out.String())
+	}
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	if err := Write(&strings.Builder{}, nil, "xml"); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,569 @@
+// Package specdiff compares two versions of an OpenAPI document and
+// classifies their differences as breaking or not for existing clients.
+package specdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Change is a difference between two versions of a document. Location uses
+// the notation of the generator diagnostics, e.g.
+// "GET /pets parameter limit" or
+// "POST /pets request application/json/properties/name".
+type Change struct {
+	Location string `json:"location"`
+	Message  string `json:"message"`
+	Breaking bool   `json:"breaking"`
+}
+
+// direction tells whether a schema is sent by clients or received by them,
+// which decides whether narrowing or widening it breaks them.
+type direction int
+
+const (
+	request direction = iota
+	response
+)
+
+// Compare returns the changes from base to revision, sorted by location.
+func Compare(base, revision *openapi3.T) []Change {
+	c := comparer{}
+	c.paths(base.Paths, revision.Paths)
+	sort.SliceStable(c.changes, func(i, j int) bool { return c.changes[i].Location < c.changes[j].Location })
+	return c.changes
+}
+
+// Breaking counts the breaking changes.
+func Breaking(changes []Change) int {
+	n := 0
+	for _, change := range changes {
+		if change.Breaking {
+			n++
+		}
+	}
+	return n
+}
+
+type comparer struct {
+	changes []Change
+}
+
+func (c *comparer) report(at string, breaking bool, format string, args ...any) {
+	c.changes = append(c.changes, Change{Location: at, Message: fmt.Sprintf(format, args...), Breaking: breaking})
+}
+
+var pathParam = regexp.MustCompile(`\{[^}]*\}`)
+
+// paths matches the paths of both versions regardless of the names of
+// their parameters, so renaming /pets/{id} to /pets/{petId} is no change.
+func (c *comparer) paths(base, revision *openapi3.Paths) {
+	byTemplate := func(paths *openapi3.Paths) map[string]string {
+		templates := map[string]string{}
+		if paths != nil {
+			for path := range paths.Map() {
+				templates[pathParam.ReplaceAllString(path, "{}")] = path
+			}
+		}
+		return templates
+	}
+	basePaths, revisionPaths := byTemplate(base), byTemplate(revision)
+	for _, template := range union(basePaths, revisionPaths) {
+		basePath, inBase := basePaths[template]
+		revisionPath, inRevision := revisionPaths[template]
+		var baseItem, revisionItem *openapi3.PathItem
+		if inBase {
+			baseItem = base.Value(basePath)
+		} else {
+			baseItem = &openapi3.PathItem{}
+		}
+		if inRevision {
+			revisionItem = revision.Value(revisionPath)
+		} else {
+			revisionItem, revisionPath = &openapi3.PathItem{}, basePath
+		}
+		c.pathItem(basePath, revisionPath, baseItem, revisionItem)
+	}
+}
+
+// pathItem compares the operations of a path, named after its revision. The
+// base path may differ in the names of its parameters.
+func (c *comparer) pathItem(basePath, path string, base, revision *openapi3.PathItem) {
+	baseOps, revisionOps := base.Operations(), revision.Operations()
+	for _, method := range union(baseOps, revisionOps) {
+		at := strings.ToUpper(method) + " " + path
+		baseOp, revisionOp := baseOps[method], revisionOps[method]
+		switch {
+		case revisionOp == nil:
+			c.report(at, true, "operation removed")
+		case baseOp == nil:
+			c.report(at, false, "operation added")
+		default:
+			c.parameters(at, parameters(basePath, base, baseOp), parameters(path, revision, revisionOp))
+			c.operation(at, baseOp, revisionOp)
+		}
+	}
+}
+
+func (c *comparer) operation(at string, base, revision *openapi3.Operation) {
+	if !base.Deprecated && revision.Deprecated {
+		c.report(at, false, "operation deprecated")
+	}
+	c.requestBody(at+" request", base.RequestBody, revision.RequestBody)
+	c.responses(at, base.Responses, revision.Responses)
+}
+
+// parameters returns the parameters of an operation, including those of
+// its path item it does not override, keyed by location and name. Path
+// parameters are keyed by their position in the path instead, renaming
+// them does not change the API.
+func parameters(path string, item *openapi3.PathItem, operation *openapi3.Operation) map[string]*openapi3.Parameter {
+	positions := map[string]int{}
+	for i, match := range pathParam.FindAllString(path, -1) {
+		positions[strings.Trim(match, "{}")] = i
+	}
+	params := map[string]*openapi3.Parameter{}
+	for _, refs := range []openapi3.Parameters{item.Parameters, operation.Parameters} {
+		for _, ref := range refs {
+			if ref.Value == nil {
+				continue
+			}
+			key := ref.Value.In + " " + ref.Value.Name
+			if i, ok := positions[ref.Value.Name]; ok && ref.Value.In == openapi3.ParameterInPath {
+				key = fmt.Sprintf("path #%d", i)
+			}
+			params[key] = ref.Value
+		}
+	}
+	return params
+}
+
+func (c *comparer) parameters(at string, base, revision map[string]*openapi3.Parameter) {
+	for _, key := range union(base, revision) {
+		baseParam, revisionParam := base[key], revision[key]
+		switch {
+		case revisionParam == nil:
+			c.report(fmt.Sprintf("%s parameter %s", at, baseParam.Name), true, "%s parameter removed", baseParam.In)
+		case baseParam == nil:
+			required := revisionParam.Required || revisionParam.In == openapi3.ParameterInPath
+			c.report(fmt.Sprintf("%s parameter %s", at, revisionParam.Name), required, "%s %s parameter added", requiredWord(required), revisionParam.In)
+		default:
+			paramAt := fmt.Sprintf("%s parameter %s", at, revisionParam.Name)
+			if !baseParam.Required && revisionParam.Required {
+				c.report(paramAt, true, "became required")
+			} else if baseParam.Required && !revisionParam.Required {
+				c.report(paramAt, false, "became optional")
+			}
+			c.schema(paramAt, request, baseParam.Schema, revisionParam.Schema, map[[2]*openapi3.Schema]bool{})
+		}
+	}
+}
+
+func (c *comparer) requestBody(at string, base, revision *openapi3.RequestBodyRef) {
+	var baseBody, revisionBody openapi3.RequestBody
+	if base != nil && base.Value != nil {
+		baseBody = *base.Value
+	}
+	if revision != nil && revision.Value != nil {
+		revisionBody = *revision.Value
+	}
+	switch {
+	case baseBody.Content == nil && revisionBody.Content == nil:
+		return
+	case revisionBody.Content == nil:
+		c.report(at, false, "request body removed")
+		return
+	case baseBody.Content == nil:
+		c.report(at, revisionBody.Required, "%s request body added", requiredWord(revisionBody.Required))
+		return
+	}
+	if !baseBody.Required && revisionBody.Required {
+		c.report(at, true, "request body became required")
+	}
+	c.content(at, request, baseBody.Content, revisionBody.Content)
+}
+
+func (c *comparer) responses(at string, base, revision *openapi3.Responses) {
+	baseResponses, revisionResponses := map[string]*openapi3.ResponseRef{}, map[string]*openapi3.ResponseRef{}
+	if base != nil {
+		baseResponses = base.Map()
+	}
+	if revision != nil {
+		revisionResponses = revision.Map()
+	}
+	for _, status := range union(baseResponses, revisionResponses) {
+		statusAt := at + " response " + status
+		baseResp, revisionResp := baseResponses[status], revisionResponses[status]
+		switch {
+		case revisionResp == nil || revisionResp.Value == nil:
+			// Clients relying on a success response break, error responses
+			// are handled generically.
+			c.report(statusAt, strings.HasPrefix(status, "2"), "response removed")
+		case baseResp == nil || baseResp.Value == nil:
+			c.report(statusAt, false, "response added")
+		default:
+			c.content(statusAt, response, baseResp.Value.Content, revisionResp.Value.Content)
+		}
+	}
+}
+
+func (c *comparer) content(at string, dir direction, base, revision openapi3.Content) {
+	for _, mediaType := range union(base, revision) {
+		mediaAt := at + " " + mediaType
+		baseMedia, revisionMedia := base[mediaType], revision[mediaType]
+		switch {
+		case revisionMedia == nil:
+			c.report(mediaAt, true, "media type removed")
+		case baseMedia == nil:
+			c.report(mediaAt, false, "media type added")
+		default:
+			c.schema(mediaAt, dir, baseMedia.Schema, revisionMedia.Schema, map[[2]*openapi3.Schema]bool{})
+		}
+	}
+}
+
+// schema compares two schemas of a request or response. A request breaks
+// when the revision accepts less than the base, a response when it may
+// return more.
+func (c *comparer) schema(at string, dir direction, base, revision *openapi3.SchemaRef, visited map[[2]*openapi3.Schema]bool) {
+	if base == nil || revision == nil || base.Value == nil || revision.Value == nil {
+		if (base == nil || base.Value == nil) != (revision == nil || revision.Value == nil) {
+			c.report(at, true, "schema changed")
+		}
+		return
+	}
+	pair := [2]*openapi3.Schema{base.Value, revision.Value}
+	if visited[pair] {
+		return
+	}
+	visited[pair] = true
+	baseSchema, revisionSchema := base.Value, revision.Value
+
+	baseTypes, revisionTypes := types(baseSchema), types(revisionSchema)
+	if !slices.Equal(baseTypes, revisionTypes) {
+		widened := contains(revisionTypes, baseTypes)
+		narrowed := contains(baseTypes, revisionTypes)
+		c.report(at, dir == request && !widened || dir == response && !narrowed,
+			"type changed from %s to %s", typeName(baseTypes), typeName(revisionTypes))
+	}
+	if baseSchema.Format != revisionSchema.Format {
+		widened := formatContains(revisionSchema.Format, baseSchema.Format)
+		narrowed := formatContains(baseSchema.Format, revisionSchema.Format)
+		c.report(at, dir == request && !widened || dir == response && !narrowed,
+			"format changed from %q to %q", baseSchema.Format, revisionSchema.Format)
+	}
+	c.constraints(at, dir, baseSchema, revisionSchema)
+	c.enum(at, dir, baseSchema.Enum, revisionSchema.Enum)
+	c.composition(at, "allOf", dir, baseSchema.AllOf, revisionSchema.AllOf, visited)
+	c.composition(at, "anyOf", dir, baseSchema.AnyOf, revisionSchema.AnyOf, visited)
+	c.composition(at, "oneOf", dir, baseSchema.OneOf, revisionSchema.OneOf, visited)
+	switch {
+	case baseSchema.Not == nil && revisionSchema.Not == nil:
+	case baseSchema.Not == nil:
+		c.report(at+"/not", dir == request, "not added")
+	case revisionSchema.Not == nil:
+		c.report(at+"/not", dir == response, "not removed")
+	default:
+		// Widening the excluded schema narrows the schema, and the reverse.
+		opposite := response
+		if dir == response {
+			opposite = request
+		}
+		c.schema(at+"/not", opposite, baseSchema.Not, revisionSchema.Not, visited)
+	}
+
+	for _, name := range union(baseSchema.Properties, revisionSchema.Properties) {
+		propAt := at + "/properties/" + name
+		baseProp, revisionProp := baseSchema.Properties[name], revisionSchema.Properties[name]
+		baseRequired := slices.Contains(baseSchema.Required, name)
+		revisionRequired := slices.Contains(revisionSchema.Required, name)
+		switch {
+		case revisionProp == nil:
+			// Clients may still send it, but no longer receive it.
+			c.report(propAt, dir == response, "property removed")
+		case baseProp == nil:
+			c.report(propAt, dir == request && revisionRequired, "%s property added", requiredWord(revisionRequired))
+		default:
+			if !baseRequired && revisionRequired {
+				c.report(propAt, dir == request, "became required")
+			} else if baseRequired && !revisionRequired {
+				c.report(propAt, dir == response, "became optional")
+			}
+			c.schema(propAt, dir, baseProp, revisionProp, visited)
+		}
+	}
+	if baseSchema.Items != nil || revisionSchema.Items != nil {
+		c.schema(at+"/items", dir, baseSchema.Items, revisionSchema.Items, visited)
+	}
+	if baseSchema.AdditionalProperties.Schema != nil && revisionSchema.AdditionalProperties.Schema != nil {
+		c.schema(at+"/additionalProperties", dir, baseSchema.AdditionalProperties.Schema, revisionSchema.AdditionalProperties.Schema, visited)
+	}
+}
+
+// constraints compares the validation keywords of two schemas. Tightening
+// one narrows the schema, which breaks requests, loosening it widens the
+// schema, which breaks responses.
+func (c *comparer) constraints(at string, dir direction, base, revision *openapi3.Schema) {
+	c.bound(at, "minLength", dir, true, count(base.MinLength), count(revision.MinLength))
+	c.bound(at, "maxLength", dir, false, limit(base.MaxLength), limit(revision.MaxLength))
+	c.bound(at, "minItems", dir, true, count(base.MinItems), count(revision.MinItems))
+	c.bound(at, "maxItems", dir, false, limit(base.MaxItems), limit(revision.MaxItems))
+	c.bound(at, "minProperties", dir, true, count(base.MinProps), count(revision.MinProps))
+	c.bound(at, "maxProperties", dir, false, limit(base.MaxProps), limit(revision.MaxProps))
+	c.bound(at, "minimum", dir, true, number(base.Min, base.ExclusiveMin), number(revision.Min, revision.ExclusiveMin))
+	c.bound(at, "maximum", dir, false, number(base.Max, base.ExclusiveMax), number(revision.Max, revision.ExclusiveMax))
+
+	switch {
+	case base.Pattern == revision.Pattern:
+	case base.Pattern == "":
+		c.report(at, dir == request, "pattern %q added", revision.Pattern)
+	case revision.Pattern == "":
+		c.report(at, dir == response, "pattern %q removed", base.Pattern)
+	default:
+		// Whether one pattern matches less than the other is undecidable here.
+		c.report(at, true, "pattern changed from %q to %q", base.Pattern, revision.Pattern)
+	}
+
+	switch {
+	case base.MultipleOf == nil && revision.MultipleOf == nil:
+	case base.MultipleOf == nil:
+		c.report(at, dir == request, "multipleOf %v added", *revision.MultipleOf)
+	case revision.MultipleOf == nil:
+		c.report(at, dir == response, "multipleOf %v removed", *base.MultipleOf)
+	case *base.MultipleOf != *revision.MultipleOf:
+		widened := isMultiple(*base.MultipleOf, *revision.MultipleOf)
+		narrowed := isMultiple(*revision.MultipleOf, *base.MultipleOf)
+		c.report(at, dir == request && !widened || dir == response && !narrowed,
+			"multipleOf changed from %v to %v", *base.MultipleOf, *revision.MultipleOf)
+	}
+
+	if !base.UniqueItems && revision.UniqueItems {
+		c.report(at, dir == request, "uniqueItems added")
+	} else if base.UniqueItems && !revision.UniqueItems {
+		c.report(at, dir == response, "uniqueItems removed")
+	}
+}
+
+// threshold is a lower or upper bound of a schema.
+type threshold struct {
+	value     float64
+	exclusive bool
+}
+
+func (l threshold) String() string {
+	if l.exclusive {
+		return "exclusive " + strconv.FormatFloat(l.value, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(l.value, 'g', -1, 64)
+}
+
+// count returns a minLength, minItems or minProperties bound, none for 0.
+func count(n uint64) *threshold {
+	if n == 0 {
+		return nil
+	}
+	return &threshold{value: float64(n)}
+}
+
+// limit returns a maxLength, maxItems or maxProperties bound.
+func limit(n *uint64) *threshold {
+	if n == nil {
+		return nil
+	}
+	return &threshold{value: float64(*n)}
+}
+
+// number returns a minimum or maximum bound.
+func number(n *float64, exclusive bool) *threshold {
+	if n == nil {
+		return nil
+	}
+	return &threshold{value: *n, exclusive: exclusive}
+}
+
+// bound compares a lower or upper bound: raising a lower bound or lowering
+// an upper one narrows the schema, making it exclusive too.
+func (c *comparer) bound(at, keyword string, dir direction, lower bool, base, revision *threshold) {
+	switch {
+	case base == nil && revision == nil:
+	case base == nil:
+		c.report(at, dir == request, "%s %s added", keyword, revision)
+	case revision == nil:
+		c.report(at, dir == response, "%s %s removed", keyword, base)
+	case *base != *revision:
+		tighter := revision.value > base.value
+		if !lower {
+			tighter = revision.value < base.value
+		}
+		narrowed := tighter || revision.value == base.value && revision.exclusive
+		c.report(at, dir == request && narrowed || dir == response && !narrowed,
+			"%s changed from %s to %s", keyword, base, revision)
+	}
+}
+
+// isMultiple reports whether every multiple of n is a multiple of d.
+func isMultiple(n, d float64) bool {
+	q := n / d
+	return q == math.Trunc(q)
+}
+
+// composition compares the branches of allOf, anyOf or oneOf. Every branch
+// of allOf constrains the schema, so adding one narrows it, while adding a
+// branch to an existing anyOf or oneOf widens it.
+func (c *comparer) composition(at, keyword string, dir direction, base, revision openapi3.SchemaRefs, visited map[[2]*openapi3.Schema]bool) {
+	if len(base) == 0 && len(revision) == 0 {
+		return
+	}
+	if len(base) == 0 {
+		c.report(at+"/"+keyword, dir == request, "%s added", keyword)
+		return
+	}
+	if len(revision) == 0 {
+		c.report(at+"/"+keyword, dir == response, "%s removed", keyword)
+		return
+	}
+	narrows := keyword == "allOf"
+	baseBranches, revisionBranches := branches(base), branches(revision)
+	for _, key := range union(baseBranches, revisionBranches) {
+		branchAt := at + "/" + keyword + "/" + key
+		baseBranch, revisionBranch := baseBranches[key], revisionBranches[key]
+		switch {
+		case revisionBranch == nil:
+			c.report(branchAt, dir == request && !narrows || dir == response && narrows, "%s branch removed", keyword)
+		case baseBranch == nil:
+			c.report(branchAt, dir == request && narrows || dir == response && !narrows, "%s branch added", keyword)
+		default:
+			c.schema(branchAt, dir, baseBranch, revisionBranch, visited)
+		}
+	}
+}
+
+// branches keys the subschemas of a composition by the component schema
+// they refer to, inline ones by their position among the inline ones, so
+// that adding or removing a branch does not shift the others.
+func branches(refs openapi3.SchemaRefs) map[string]*openapi3.SchemaRef {
+	keyed := map[string]*openapi3.SchemaRef{}
+	inline := 0
+	for _, ref := range refs {
+		if name, ok := strings.CutPrefix(ref.Ref, "#/components/schemas/"); ok {
+			keyed[name] = ref
+			continue
+		}
+		keyed[strconv.Itoa(inline)] = ref
+		inline++
+	}
+	return keyed
+}
+
+// enum reports the values added to or removed from an enum. Removing a value
+// breaks requests, adding one breaks clients switching over responses.
+func (c *comparer) enum(at string, dir direction, base, revision []any) {
+	if len(base) == 0 && len(revision) == 0 {
+		return
+	}
+	if len(base) == 0 {
+		c.report(at, dir == request, "enum added")
+		return
+	}
+	if len(revision) == 0 {
+		c.report(at, dir == response, "enum removed")
+		return
+	}
+	baseValues, revisionValues := enumValues(base), enumValues(revision)
+	for _, value := range union(baseValues, revisionValues) {
+		switch {
+		case !revisionValues[value]:
+			c.report(at, dir == request, "enum value %s removed", value)
+		case !baseValues[value]:
+			c.report(at, dir == response, "enum value %s added", value)
+		}
+	}
+}
+
+func enumValues(values []any) map[string]bool {
+	set := map[string]bool{}
+	for _, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			data = []byte(fmt.Sprint(value))
+		}
+		set[string(data)] = true
+	}
+	return set
+}
+
+// types returns the sorted types of a schema, nil when it allows any.
+func types(schema *openapi3.Schema) []string {
+	if schema.Type == nil || len(*schema.Type) == 0 {
+		return nil
+	}
+	list := slices.Clone([]string(*schema.Type))
+	if schema.Nullable && !slices.Contains(list, openapi3.TypeNull) {
+		list = append(list, openapi3.TypeNull)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// contains reports whether the types of outer allow every type of inner.
+func contains(outer, inner []string) bool {
+	if outer == nil {
+		return true
+	}
+	if inner == nil {
+		return false
+	}
+	for _, t := range inner {
+		if !slices.Contains(outer, t) && !(t == openapi3.TypeInteger && slices.Contains(outer, openapi3.TypeNumber)) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatContains reports whether the format outer allows every value of
+// the format inner. No format allows any value.
+func formatContains(outer, inner string) bool {
+	switch {
+	case outer == "" || outer == inner:
+		return true
+	case outer == "int64" && inner == "int32", outer == "double" && inner == "float":
+		return true
+	}
+	return false
+}
+
+func typeName(types []string) string {
+	if types == nil {
+		return "any"
+	}
+	return strings.Join(types, "|")
+}
+
+func requiredWord(required bool) string {
+	if required {
+		return "required"
+	}
+	return "optional"
+}
+
+// union returns the sorted keys of both maps.
+func union[V1, V2 any, M1 ~map[string]V1, M2 ~map[string]V2](a M1, b M2) []string {
+	keys := slices.Collect(maps.Keys(a))
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,222 @@
+package specdiff
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func loadDoc(t *testing.T, spec string) *openapi3.T {
+	t.Helper()
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(spec))
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	return doc
+}
+
+const baseSpec = `
+openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /pets/{id}:
+    parameters:
+      - {name: id, in: path, required: true, schema: {type: integer}}
+    get:
+      operationId: getPet
+      parameters:
+        - {name: fields, in: query, schema: {type: string}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/Pet'}
+        "404": {description: not found}
+  /pets:
+    post:
+      operationId: addPet
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/Pet'}
+      responses:
+        "201": {description: created}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string}
+        age: {type: integer}
+        status: {type: string, enum: [available, sold]}
+        parent: {$ref: '#/components/schemas/Pet'}
+        tag: {type: string, maxLength: 10, pattern: '^[a-z]+$'}
+        weight: {type: number, minimum: 0}
+        photos: {type: array, items: {type: string}, maxItems: 5}
+        owner:
+          oneOf:
+            - {$ref: '#/components/schemas/Person'}
+            - {type: string, format: email}
+    Person:
+      type: object
+      properties:
+        name: {type: string}
+`
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		want     []Change
+	}{
+		{name: "no change", from: "", to: ""},
+		{name: "renamed path parameter",
+			from: "  /pets/{id}:\n    parameters:\n      - {name: id,",
+			to:   "  /pets/{petId}:\n    parameters:\n      - {name: petId,"},
+		{name: "added required query parameter",
+			from: "        - {name: fields, in: query, schema: {type: string}}\n",
+			to:   "        - {name: fields, in: query, schema: {type: string}}\n        - {name: v, in: query, required: true, schema: {type: string}}\n",
+			want: []Change{{"GET /pets/{id} parameter v", "required query parameter added", true}}},
+		{name: "parameter became required",
+			from: "{name: fields, in: query, schema",
+			to:   "{name: fields, in: query, required: true, schema",
+			want: []Change{{"GET /pets/{id} parameter fields", "became required", true}}},
+		{name: "parameter type widened",
+			from: "{name: id, in: path, required: true, schema: {type: integer}}",
+			to:   "{name: id, in: path, required: true, schema: {type: number}}",
+			want: []Change{{"GET /pets/{id} parameter id", "type changed from integer to number", false}}},
+		{name: "enum value added",
+			from: "enum: [available, sold]",
+			to:   "enum: [available, pending, sold]",
+			want: []Change{
+				{"GET /pets/{id} response 200 application/json/properties/status", `enum value "pending" added`, true},
+				{"POST /pets request application/json/properties/status", `enum value "pending" added`, false},
+			}},
+		{name: "property removed",
+			from: "        age: {type: integer}\n",
+			to:   "",
+			want: []Change{
+				{"GET /pets/{id} response 200 application/json/properties/age", "property removed", true},
+				{"POST /pets request application/json/properties/age", "property removed", false},
+			}},
+		{name: "property became optional",
+			from: "required: [name]",
+			to:   "required: []",
+			want: []Change{
+				{"GET /pets/{id} response 200 application/json/properties/name", "became optional", true},
+				{"POST /pets request application/json/properties/name", "became optional", false},
+			}},
+		{name: "format removed",
+			from: "{type: string, format: email}",
+			to:   "{type: string}",
+			want: []Change{
+				{"GET /pets/{id} response 200 application/json/properties/owner/oneOf/0", `format changed from "email" to ""`, true},
+				{"POST /pets request application/json/properties/owner/oneOf/0", `format changed from "email" to ""`, false},
+			}},
+		{name: "format widened",
+			from: "age: {type: integer}",
+			to:   "age: {type: integer, format: int64}",
+			want: []Change{
+				{"GET /pets/{id} response 200 application/json/properties/age", `format changed from "" to "int64"`, false},
+				{"POST /pets request application/json/properties/age", `format changed from "" to "int64"`, true},
+			}},
+		{name: "maxLength lowered",
+			from: "maxLength: 10",
+			to:   "maxLength: 5",
+			want: []Change{
+				{"GET /pets/{id} response 200 application/json/properties/tag", "maxLength changed from 10 to 5", false},
+				{"POST /pets request application/json/properties/tag", "maxLength changed from 10 to 5", true},
+			}},
+		{name: "maxItems raised",
+			from: "maxItems: 5",
+			to:   "maxItems: 10",
+			want: []Change{
+				{"GET /pets/{id} response 200 application/json/properties/photos", "maxItems changed from 5 to 10", true},
+				{"POST /pets request application/json/properties/photos", "maxItems changed from 5 to 10", false},
+			}},
+		{name: "minimum made exclusive",
+			from: "minimum: 0}",
+			to:   "minimum: 0, exclusiveMinimum: true}",
+			want: []Change{
+				{"GET /pets/{id} response 200 application/json/properties/weight", "minimum changed from 0 to exclusive 0", false},
+				{"POST /pets request application/json/properties/weight", "minimum changed from 0 to exclusive 0", true},
+			}},
+		{name: "pattern removed",
+			from: ", pattern: '^[a-z]+$'",
+			to:   "",
+			want: []Change{
+				{"GET /pets/{id} response 200 application/json/properties/tag", `pattern "^[a-z]+$" removed`, true},
+				{"POST /pets request application/json/properties/tag", `pattern "^[a-z]+$" removed`, false},
+			}},
+		{name: "parameter minLength added",
+			from: "{name: fields, in: query, schema: {type: string}}",
+			to:   "{name: fields, in: query, schema: {type: string, minLength: 2}}",
+			want: []Change{{"GET /pets/{id} parameter fields", "minLength 2 added", true}}},
+		{name: "oneOf branch added",
+			from: "            - {type: string, format: email}\n",
+			to:   "            - {type: string, format: email}\n            - {type: integer}\n",
+			want: []Change{
+				{"GET /pets/{id} response 200 application/json/properties/owner/oneOf/1", "oneOf branch added", true},
+				{"POST /pets request application/json/properties/owner/oneOf/1", "oneOf branch added", false},
+			}},
+		{name: "oneOf branch removed",
+			from: "            - {$ref: '#/components/schemas/Person'}\n",
+			to:   "",
+			want: []Change{
+				{"GET /pets/{id} response 200 application/json/properties/owner/oneOf/Person", "oneOf branch removed", false},
+				{"POST /pets request application/json/properties/owner/oneOf/Person", "oneOf branch removed", true},
+			}},
+		{name: "oneOf branch changed",
+			from: "    Person:\n      type: object\n",
+			to:   "    Person:\n      type: object\n      required: [name]\n",
+			want: []Change{
+				{"GET /pets/{id} response 200 application/json/properties/owner/oneOf/Person/properties/name", "became required", false},
+				{"POST /pets request application/json/properties/owner/oneOf/Person/properties/name", "became required", true},
+			}},
+		{name: "allOf added",
+			from: "    Person:\n      type: object\n",
+			to:   "    Person:\n      type: object\n      allOf: [{required: [name]}]\n",
+			want: []Change{
+				{"GET /pets/{id} response 200 application/json/properties/owner/oneOf/Person/allOf", "allOf added", false},
+				{"POST /pets request application/json/properties/owner/oneOf/Person/allOf", "allOf added", true},
+			}},
+		{name: "success response removed",
+			from: "      responses:\n        \"201\": {description: created}\n",
+			to:   "      responses:\n        \"202\": {description: accepted}\n",
+			want: []Change{
+				{"POST /pets response 201", "response removed", true},
+				{"POST /pets response 202", "response added", false},
+			}},
+		{name: "operation removed",
+			from: "  /pets:\n    post:",
+			to:   "  /pets:\n    put:",
+			want: []Change{
+				{"POST /pets", "operation removed", true},
+				{"PUT /pets", "operation added", false},
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revision := baseSpec
+			if tt.from != "" {
+				revision = replaceOnce(t, baseSpec, tt.from, tt.to)
+			}
+			got := Compare(loadDoc(t, baseSpec), loadDoc(t, revision))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected changes\n%+v\ngot\n%+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func replaceOnce(t *testing.T, s, old, new string) string {
+	t.Helper()
+	if !strings.Contains(s, old) {
+		t.Fatalf("%q not found in spec", old)
+	}
+	return strings.Replace(s, old, new, 1)
+}
@@ -5,17 +5,77 @@ type API struct {
 	Method      string
 	Path        string
 	Description string
+	// Parameters are bound into the <OperationID>Params struct.
+	Parameters  []Parameter
 	RequestBody *RequestBody
-	Response    *Response
+	// Response is the primary success response, used by the generated handler.
+	Response *Response
+	// Responses holds every declared response, ordered by status with default last.
+	Responses []Response
+	Security  []SecurityRequirement
+	// Imports are required by body types with an x-go-type, as "path" or "alias path".
+	Imports []string
+}
+
+type Parameter struct {
+	Name        string
+	GoName      string
+	GoType      string
+	In          string
+	Required    bool
+	Description string
+	// Default is the schema default in gin binding syntax.
+	Default string
+	// Tag is the struct tag binding the parameter, e.g. form:"status,default=available".
+	Tag string
 }
 
 type RequestBody struct {
-	ModelName string
+	ModelName    string
+	TypeName     string
+	ContentType  string
+	ContentTypes []string
+	// Binding is one of json, xml, bind (content negotiated), text or binary.
+	Binding string
+	// Encoding is how clients encode the body, the kind of ContentType: json,
+	// xml, form, multipart, text or binary.
+	Encoding   string
+	Required   bool
+	FormFields []FormField
+	// ClearReadOnly makes the handler clear the readOnly fields of the
+	// models in the bound body, see mapper.ApplyReadWriteOnly.
+	ClearReadOnly bool
+}
+
+type FormField struct {
+	Name   string
+	GoName string
+	GoType string
+	IsFile bool
 }
 
 type Response struct {
-	ModelName string
-	Status    string
+	ModelName   string
+	Status      string
+	StatusCode  int
+	ContentType string
+	// Binding is one of json, xml, form, multipart, text or binary, and
+	// decides how Write renders the body.
+	Binding     string
+	Description string
+	TypeName    string
+	Headers     []ResponseHeader
+	// OmitWriteOnly makes Write leave the writeOnly fields of the models in
+	// the body out of JSON and clear them in XML, see
+	// mapper.ApplyReadWriteOnly.
+	OmitWriteOnly bool
+}
+
+type ResponseHeader struct {
+	Name        string
+	GoName      string
+	GoType      string
+	Description string
 }
 
 type APIs map[string][]API
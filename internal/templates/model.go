@@ -5,6 +5,30 @@ type Model struct {
 	OriginalName string
 	Fields       []ModelProp
 	Description  string
+	// Imports are required by fields with an x-go-type, as "path" or "alias path".
+	Imports []string
+	// UnmarshalDefaults adds an UnmarshalJSON method filling absent fields
+	// with their defaults, see mapper.ApplyDefaultsOnUnmarshal.
+	UnmarshalDefaults bool
+	// OmitWriteOnly lists the writeOnly fields for the responses to leave
+	// out, and IgnoreReadOnly the readOnly fields for the request bodies to
+	// clear, see mapper.ApplyReadWriteOnly.
+	OmitWriteOnly  bool
+	IgnoreReadOnly bool
+	// RequiredKeys are the JSON names of the required fields whose zero value
+	// is valid, so UnmarshalJSON reports them when absent, see
+	// mapper.ApplyRequiredOnUnmarshal.
+	RequiredKeys []string
+	// Conditions are the if/then/else checks of the Validate method.
+	Conditions []Condition
+}
+
+// Condition requires the Then fields when the If expression holds and the
+// Else fields otherwise.
+type Condition struct {
+	If   string
+	Then []ModelProp
+	Else []ModelProp
 }
 
 type ModelProp struct {
@@ -12,4 +36,32 @@ type ModelProp struct {
 	GoType      string
 	JSONName    string
 	Description string
+	Required    bool
+	// Nullable is set for the scalars that are pointers because they allow
+	// null, Validate checks the value they point to.
+	Nullable bool
+	// Nested is "model" when the field is a generated model, "pointer" when it
+	// points to one and "models" when it is a slice of them, so Validate can
+	// recurse into it.
+	Nested      string
+	Validations []Validation
+	// XMLName is the xml tag value derived from the schema xml object.
+	XMLName string
+	// ValidateTag is the go-playground validate tag derived from the constraints.
+	ValidateTag string
+	OmitEmpty   bool
+	JSONIgnore  bool
+	// Default is the schema default as a Go literal.
+	Default   string
+	ReadOnly  bool
+	WriteOnly bool
+	// Tags is the complete struct tag, see mapper.ApplyStructTags.
+	Tags string
+}
+
+// Validation is a constraint check rendered as a call to one of the helpers
+// of the models package: Func(errs, path, value, Args).
+type Validation struct {
+	Func string
+	Args string
 }
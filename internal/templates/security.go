@@ -0,0 +1,23 @@
+package templates
+
+type SecurityScheme struct {
+	Name        string
+	GoName      string
+	Type        string
+	Scheme      string
+	In          string
+	ParamName   string
+	Description string
+}
+
+// SecurityRequirement lists the schemes, with their required scopes, that
+// must all be satisfied. An operation is accessible if any one of its
+// requirements is met.
+type SecurityRequirement struct {
+	Schemes []SecurityScopes
+}
+
+type SecurityScopes struct {
+	Name   string
+	Scopes []string
+}